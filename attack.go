@@ -0,0 +1,414 @@
+package httpfuzz
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// AttackMode controls how payloads from wordlists are combined with the injection points in the seed request.
+type AttackMode string
+
+const (
+	// SniperAttack places each word from the wordlist into one target at a time.
+	SniperAttack AttackMode = "sniper"
+	// PitchforkAttack binds a wordlist to each target and advances all of them in lockstep.
+	// It stops when the shortest wordlist runs out.
+	PitchforkAttack AttackMode = "pitchfork"
+	// ClusterBombAttack binds a wordlist to each target and sends every combination of their words.
+	ClusterBombAttack AttackMode = "clusterbomb"
+	// BatteringRamAttack places each word from the wordlist into every target in the same request.
+	BatteringRamAttack AttackMode = "batteringram"
+)
+
+// ParseAttackMode validates an attack mode name from the CLI.
+// An empty name selects SniperAttack.
+func ParseAttackMode(name string) (AttackMode, error) {
+	switch mode := AttackMode(name); mode {
+	case "":
+		return SniperAttack, nil
+	case SniperAttack, PitchforkAttack, ClusterBombAttack, BatteringRamAttack:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown attack mode '%s'", name)
+	}
+}
+
+// targetLocations maps the location names accepted by ParseTarget to the locations reported on a Job.
+var targetLocations = map[string]string{
+	"header":   headerLocation,
+	"param":    urlParamLocation,
+	"path-arg": urlPathArgLocation,
+	"body":     bodyLocation,
+}
+
+// Target is a single injection point in the seed request bound to its own wordlist.
+// Body targets are addressed by delimiter position, or by form field name in multipart requests.
+// Each Target needs its own Wordlist, since a Wordlist can only be streamed once at a time.
+type Target struct {
+	Location  string
+	FieldName string
+	Wordlist  *Wordlist
+}
+
+// ParseTarget parses a target in the form location:field=wordlist, like header:User-Agent=agents.txt.
+// Valid locations are header, param, path-arg and body.
+// The wordlist file is opened and must be closed by the caller.
+func ParseTarget(spec string) (*Target, error) {
+	separator := strings.Index(spec, ":")
+	if separator == -1 {
+		return nil, fmt.Errorf("target '%s' must be in the form location:field=wordlist", spec)
+	}
+
+	location, found := targetLocations[spec[:separator]]
+	if !found {
+		return nil, fmt.Errorf("unknown target location '%s'", spec[:separator])
+	}
+
+	binding := spec[separator+1:]
+	separator = strings.LastIndex(binding, "=")
+	if separator < 1 || separator == len(binding)-1 {
+		return nil, fmt.Errorf("target '%s' must be in the form location:field=wordlist", spec)
+	}
+
+	fieldName := binding[:separator]
+	wordlistFile, err := os.Open(binding[separator+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Target{
+		Location:  location,
+		FieldName: fieldName,
+		Wordlist:  &Wordlist{File: wordlistFile},
+	}, nil
+}
+
+// HasTarget returns false if a target refers to a URL path argument that isn't in the request.
+// Headers, query params and body targets are created if they are missing, so they are always present.
+func (r *Request) HasTarget(target *Target) bool {
+	if target.Location == urlPathArgLocation {
+		return r.HasPathArgument(target.FieldName)
+	}
+	return true
+}
+
+// inject places a payload at this target in a request.
+// Delimited body positions are skipped, since they're filled in all at once by SetBodyPayloads.
+func (t *Target) inject(req *Request, payload string) error {
+	switch t.Location {
+	case headerLocation:
+		req.Header.Set(t.FieldName, payload)
+	case urlParamLocation:
+		req.SetQueryParam(t.FieldName, payload)
+	case urlPathArgLocation:
+		req.SetURLPathArgument(t.FieldName, payload)
+	case bodyLocation:
+		if req.IsMultipartForm() {
+			return req.ReplaceMultipartField(t.FieldName, payload)
+		}
+	default:
+		return fmt.Errorf("unknown target location '%s'", t.Location)
+	}
+	return nil
+}
+
+// bodyPosition returns the delimiter position of a body target, or -1 if it isn't one.
+func (t *Target) bodyPosition() int {
+	if t.Location != bodyLocation {
+		return -1
+	}
+
+	position, err := strconv.Atoi(t.FieldName)
+	if err != nil {
+		return -1
+	}
+	return position
+}
+
+// injectTargets copies the seed and places payloads[i] at targets[i].
+func injectTargets(seed *Request, targets []*Target, payloads []string, delimiter byte) (*Request, error) {
+	req, err := seed.CloneBody(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	bodyPayloads := map[int]string{}
+	for i, target := range targets {
+		if target.Location == bodyLocation && !req.IsMultipartForm() {
+			position := target.bodyPosition()
+			if position == -1 {
+				return nil, fmt.Errorf("body target '%s' is not a delimiter position", target.FieldName)
+			}
+			bodyPayloads[position] = payloads[i]
+			continue
+		}
+
+		err = target.inject(req, payloads[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Every body position is filled in from the seed's delimiter offsets in one pass, so delimiters in payloads are sent as they are.
+	if req.IsMultipartForm() {
+		return req, nil
+	}
+
+	err = req.SetBodyPayloads(bodyPayloads, delimiter)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// multiTargetJob describes a request with a payload in several targets at once.
+func multiTargetJob(req *Request, targets []*Target, payloads []string) *Job {
+	locations := make([]string, len(targets))
+	fieldNames := make([]string, len(targets))
+	for i, target := range targets {
+		locations[i] = target.Location
+		fieldNames[i] = target.FieldName
+	}
+
+	injections := make([]*Injection, len(targets))
+	for i, target := range targets {
+		injections[i] = &Injection{
+			Location:  target.Location,
+			FieldName: target.FieldName,
+			Payload:   payloads[i],
+		}
+	}
+
+	return &Job{
+		Request:    req,
+		FieldName:  strings.Join(fieldNames, ", "),
+		Location:   strings.Join(locations, ", "),
+		Payload:    strings.Join(payloads, ", "),
+		Injections: injections,
+	}
+}
+
+// generatePitchfork sends one request per line, taking line n from each target's wordlist.
+func (f *Fuzzer) generatePitchfork(ctx context.Context, seed *Request, start *Position, jobs chan<- *Job, errors chan<- error) {
+	if len(f.Targets) == 0 {
+		return
+	}
+
+	streams := make([]<-chan *Word, len(f.Targets))
+	for i, target := range f.Targets {
+		streams[i] = target.Wordlist.StreamFrom(start.offset(i))
+	}
+
+	// Streams hold their wordlist's lock until they've been read to the end, so drain the longer wordlists.
+	defer func() {
+		for _, stream := range streams {
+			for range stream {
+			}
+		}
+	}()
+
+	for ctx.Err() == nil {
+		payloads := make([]string, len(streams))
+		offsets := make([]int64, len(streams))
+		for i, stream := range streams {
+			word, ok := <-stream
+			if !ok {
+				return
+			}
+			payloads[i] = word.Text
+			offsets[i] = word.Offset
+		}
+
+		markPosition(jobs, wordlistPhase, offsets...)
+
+		req, err := injectTargets(seed, f.Targets, payloads, f.TargetDelimiter)
+		if err != nil {
+			errors <- err
+			return
+		}
+
+		jobs <- multiTargetJob(req, f.Targets, payloads)
+	}
+}
+
+// pitchforkRequestCount is the length of the shortest wordlist bound to a target.
+func (f *Fuzzer) pitchforkRequestCount() (int, error) {
+	if len(f.Targets) == 0 {
+		return 0, nil
+	}
+
+	shortest := -1
+	for _, target := range f.Targets {
+		count, err := target.Wordlist.Count()
+		if err != nil {
+			return 0, err
+		}
+
+		if shortest == -1 || count < shortest {
+			shortest = count
+		}
+	}
+	return shortest, nil
+}
+
+// generateClusterBomb sends one request for every combination of words in the targets' wordlists.
+func (f *Fuzzer) generateClusterBomb(ctx context.Context, seed *Request, start *Position, jobs chan<- *Job, errors chan<- error) {
+	if len(f.Targets) == 0 {
+		return
+	}
+
+	payloads := make([]string, len(f.Targets))
+	offsets := make([]int64, len(f.Targets))
+	err := f.clusterBomb(ctx, seed, 0, payloads, offsets, start, jobs)
+	if err != nil && err != ctx.Err() {
+		errors <- err
+	}
+}
+
+// clusterBomb fills in the payloads for f.Targets[depth:] and sends a job once every target has a payload.
+// Only one line of each wordlist is held in memory at a time, at the cost of re-reading the inner wordlists.
+// When resuming, the first pass through each wordlist starts from the start position instead of the top.
+func (f *Fuzzer) clusterBomb(ctx context.Context, seed *Request, depth int, payloads []string, offsets []int64, start *Position, jobs chan<- *Job) error {
+	if depth == len(f.Targets) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		markPosition(jobs, wordlistPhase, offsets...)
+		req, err := injectTargets(seed, f.Targets, payloads, f.TargetDelimiter)
+		if err != nil {
+			return err
+		}
+
+		// Copy the payloads since the next combination overwrites them.
+		jobs <- multiTargetJob(req, f.Targets, append([]string{}, payloads...))
+		return nil
+	}
+
+	// Inner wordlists are read once for every combination of the outer ones, so start each pass from the top.
+	stream := f.Targets[depth].Wordlist.StreamFrom(start.offset(depth))
+	for word := range stream {
+		payloads[depth] = word.Text
+		offsets[depth] = word.Offset
+		err := f.clusterBomb(ctx, seed, depth+1, payloads, offsets, start, jobs)
+		start = nil
+		if err != nil {
+			// Release the wordlist's lock before bailing out.
+			for range stream {
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// clusterBombRequestCount multiplies the lengths of the targets' wordlists, reading each one only once.
+func (f *Fuzzer) clusterBombRequestCount() (int, error) {
+	if len(f.Targets) == 0 {
+		return 0, nil
+	}
+
+	product := 1
+	for _, target := range f.Targets {
+		count, err := target.Wordlist.Count()
+		if err != nil {
+			return 0, err
+		}
+		product *= count
+	}
+	return product, nil
+}
+
+// batteringRamTargets lists every target header, param and path argument, along with every injection point in the body.
+// Battering ram targets share the main wordlist, so they aren't bound to their own.
+func (f *Fuzzer) batteringRamTargets(seed *Seed) ([]*Target, error) {
+	targets := []*Target{}
+	for _, header := range withSeedTargets(f.TargetHeaders, seed.TargetHeaders) {
+		targets = append(targets, &Target{Location: headerLocation, FieldName: header})
+	}
+
+	for _, param := range withSeedTargets(f.TargetParams, seed.TargetParams) {
+		targets = append(targets, &Target{Location: urlParamLocation, FieldName: param})
+	}
+
+	for _, arg := range withSeedTargets(f.TargetPathArgs, seed.TargetPathArgs) {
+		targets = append(targets, &Target{Location: urlPathArgLocation, FieldName: arg})
+	}
+
+	// Prevent delimiter code from firing for multipart requests
+	if seed.Request.IsMultipartForm() {
+		for _, fieldName := range f.TargetMultipartFieldNames {
+			targets = append(targets, &Target{Location: bodyLocation, FieldName: fieldName})
+		}
+		return targets, nil
+	}
+
+	bodyTargetCount, err := seed.Request.BodyTargetCount(f.TargetDelimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	for position := 0; position < bodyTargetCount; position++ {
+		targets = append(targets, &Target{Location: bodyLocation, FieldName: strconv.Itoa(position)})
+	}
+	return targets, nil
+}
+
+// generateBatteringRam sends one request per word, with the word in every target.
+func (f *Fuzzer) generateBatteringRam(ctx context.Context, seed *Seed, start *Position, jobs chan<- *Job, errors chan<- error) {
+	targets, err := f.batteringRamTargets(seed)
+	if err != nil {
+		errors <- err
+		return
+	}
+
+	if len(targets) == 0 {
+		return
+	}
+
+	stream := f.Wordlist.StreamFrom(start.offset(0))
+	for word := range stream {
+		if ctx.Err() != nil {
+			// Release the wordlist's lock before bailing out.
+			for range stream {
+			}
+			return
+		}
+
+		markPosition(jobs, wordlistPhase, word.Offset)
+		payload := word.Text
+		payloads := make([]string, len(targets))
+		for i := range payloads {
+			payloads[i] = payload
+		}
+
+		req, err := injectTargets(seed.Request, targets, payloads, f.TargetDelimiter)
+		if err != nil {
+			errors <- err
+			// Release the wordlist's lock before bailing out.
+			for range stream {
+			}
+			return
+		}
+
+		job := multiTargetJob(req, targets, payloads)
+		job.Payload = payload
+		jobs <- job
+	}
+}
+
+// batteringRamRequestCount is the number of words in the wordlist, since every word is sent in a single request.
+func (f *Fuzzer) batteringRamRequestCount(seed *Seed, words int) (int, error) {
+	targets, err := f.batteringRamTargets(seed)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(targets) == 0 {
+		return 0, nil
+	}
+	return words, nil
+}
@@ -0,0 +1,285 @@
+package httpfuzz
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testTarget(t *testing.T, location, fieldName, wordlistPath string) *Target {
+	wordlist, err := os.Open(wordlistPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Target{
+		Location:  location,
+		FieldName: fieldName,
+		Wordlist:  &Wordlist{File: wordlist},
+	}
+}
+
+func TestParseTargetBindsWordlistToLocation(t *testing.T) {
+	target, err := ParseTarget("header:X-Api-Key=testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Wordlist.File.Close()
+
+	if target.Location != headerLocation {
+		t.Fatalf("Expected location %s, got %s", headerLocation, target.Location)
+	}
+
+	if target.FieldName != "X-Api-Key" {
+		t.Fatalf("Expected field X-Api-Key, got %s", target.FieldName)
+	}
+}
+
+func TestParseTargetRejectsInvalidSpecs(t *testing.T) {
+	specs := []string{
+		"header",
+		"cookie:session=testdata/usernames.txt",
+		"header:X-Api-Key",
+		"header:X-Api-Key=",
+		"header:X-Api-Key=testdata/notfound.txt",
+	}
+
+	for _, spec := range specs {
+		_, err := ParseTarget(spec)
+		if err == nil {
+			t.Fatalf("Expected error for %s", spec)
+		}
+	}
+}
+
+func TestPitchforkAdvancesWordlistsInLockstep(t *testing.T) {
+	request, _ := http.NewRequest("POST", "/login", strings.NewReader("user=`u`&pass=`p`"))
+	config := &Config{
+		AttackMode: PitchforkAttack,
+		Targets: []*Target{
+			testTarget(t, bodyLocation, "0", "testdata/usernames.txt"),
+			testTarget(t, bodyLocation, "1", "testdata/passwords.txt"),
+			testTarget(t, headerLocation, "X-User", "testdata/usernames.txt"),
+		},
+		Seed:            &Request{request},
+		TargetDelimiter: '`',
+		Logger:          testLogger(t),
+		URLScheme:       "http",
+	}
+	fuzzer := &Fuzzer{config}
+	expectedCount, err := fuzzer.RequestCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The shortest wordlist has 3 words.
+	const sanityCount = 3
+	if expectedCount != sanityCount {
+		t.Fatalf("Wrong count, expected %d, got %d", sanityCount, expectedCount)
+	}
+
+	expectedBodies := []string{
+		"user=admin&pass=hunter2",
+		"user=root&pass=toor",
+		"user=guest&pass=guest",
+	}
+	expectedUsers := []string{"admin", "root", "guest"}
+
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	count := 0
+	for job := range requests {
+		if count >= expectedCount {
+			t.Fatalf("Too many requests are being sent, expected %d, got %d", expectedCount, count+1)
+		}
+
+		body, _ := ioutil.ReadAll(job.Request.Body)
+		if string(body) != expectedBodies[count] {
+			t.Fatalf("Expected body %s, got %s", expectedBodies[count], string(body))
+		}
+
+		if job.Request.Header.Get("X-User") != expectedUsers[count] {
+			t.Fatalf("Expected X-User %s, got %s", expectedUsers[count], job.Request.Header.Get("X-User"))
+		}
+		count++
+	}
+
+	if count != expectedCount {
+		t.Fatalf("Too few requests are being sent, expected %d, got %d", expectedCount, count)
+	}
+}
+
+func TestClusterBombSendsEveryCombination(t *testing.T) {
+	request, _ := http.NewRequest("GET", "/login", nil)
+	config := &Config{
+		AttackMode: ClusterBombAttack,
+		Targets: []*Target{
+			testTarget(t, urlParamLocation, "user", "testdata/usernames.txt"),
+			testTarget(t, headerLocation, "X-Password", "testdata/passwords.txt"),
+		},
+		Seed:            &Request{request},
+		TargetDelimiter: '`',
+		Logger:          testLogger(t),
+		URLScheme:       "http",
+	}
+	fuzzer := &Fuzzer{config}
+	expectedCount, err := fuzzer.RequestCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const sanityCount = 12
+	if expectedCount != sanityCount {
+		t.Fatalf("Wrong count, expected %d, got %d", sanityCount, expectedCount)
+	}
+
+	combinations := map[string]bool{}
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	for job := range requests {
+		if len(job.Injections) != 2 {
+			t.Fatalf("Expected 2 injections, got %d", len(job.Injections))
+		}
+
+		user := job.Request.URL.Query().Get("user")
+		password := job.Request.Header.Get("X-Password")
+		if user != job.Injections[0].Payload || password != job.Injections[1].Payload {
+			t.Fatalf("Request does not match injections %+v, %+v", *job.Injections[0], *job.Injections[1])
+		}
+
+		combination := user + ":" + password
+		if combinations[combination] {
+			t.Fatalf("Combination %s sent twice", combination)
+		}
+		combinations[combination] = true
+
+		if len(combinations) > expectedCount {
+			t.Fatalf("Too many requests are being sent, expected %d, got %d", expectedCount, len(combinations))
+		}
+	}
+
+	if len(combinations) != expectedCount {
+		t.Fatalf("Too few requests are being sent, expected %d, got %d", expectedCount, len(combinations))
+	}
+}
+
+func TestBatteringRamPlacesPayloadInEveryTarget(t *testing.T) {
+	wordlist, err := os.Open("testdata/useragents.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, _ := http.NewRequest("POST", "/users/user", strings.NewReader("{\"a\": \"`1`\", \"b\": \"`2`\"}"))
+	config := &Config{
+		AttackMode:      BatteringRamAttack,
+		TargetHeaders:   []string{"User-Agent", "X-Forwarded-For"},
+		TargetParams:    []string{"fuzz"},
+		TargetPathArgs:  []string{"user"},
+		Wordlist:        &Wordlist{File: wordlist},
+		Seed:            &Request{request},
+		TargetDelimiter: '`',
+		Logger:          testLogger(t),
+		URLScheme:       "http",
+	}
+	fuzzer := &Fuzzer{config}
+	expectedCount, err := fuzzer.RequestCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const sanityCount = 5
+	if expectedCount != sanityCount {
+		t.Fatalf("Wrong count, expected %d, got %d", sanityCount, expectedCount)
+	}
+
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	count := 0
+	for job := range requests {
+		payload := job.Payload
+		if len(job.Injections) != 6 {
+			t.Fatalf("Expected 6 injections, got %d", len(job.Injections))
+		}
+
+		if job.Request.UserAgent() != payload || job.Request.Header.Get("X-Forwarded-For") != payload {
+			t.Fatalf("Payload %s missing from headers", payload)
+		}
+
+		if job.Request.URL.Query().Get("fuzz") != payload {
+			t.Fatalf("Payload %s missing from query", payload)
+		}
+
+		if !strings.HasSuffix(job.Request.URL.Path, "/"+payload) {
+			t.Fatalf("Payload %s missing from path %s", payload, job.Request.URL.Path)
+		}
+
+		body, _ := ioutil.ReadAll(job.Request.Body)
+		expectedBody := "{\"a\": \"" + payload + "\", \"b\": \"" + payload + "\"}"
+		if string(body) != expectedBody {
+			t.Fatalf("Expected body %s, got %s", expectedBody, string(body))
+		}
+
+		count++
+		if count > expectedCount {
+			t.Fatalf("Too many requests are being sent, expected %d, got %d", expectedCount, count)
+		}
+	}
+
+	if count != expectedCount {
+		t.Fatalf("Too few requests are being sent, expected %d, got %d", expectedCount, count)
+	}
+}
+
+func TestBatteringRamSendsPayloadsContainingTheDelimiter(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "wordlist.txt")
+	err := ioutil.WriteFile(filename, []byte("ok\n`bad\nfine"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wordlist, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	request, _ := http.NewRequest("POST", "/", strings.NewReader("{\"a\": \"`1`\", \"b\": \"`2`\"}"))
+	config := &Config{
+		AttackMode:      BatteringRamAttack,
+		Wordlist:        &Wordlist{File: wordlist},
+		Seed:            &Request{request},
+		TargetDelimiter: '`',
+		Logger:          testLogger(t),
+		URLScheme:       "http",
+	}
+	fuzzer := &Fuzzer{config}
+
+	requests, errors := fuzzer.GenerateRequests(context.Background())
+	failures := make(chan error, 1)
+	go func() {
+		for err := range errors {
+			failures <- err
+		}
+		close(failures)
+	}()
+
+	bodies := []string{}
+	for job := range requests {
+		body, _ := ioutil.ReadAll(job.Request.Body)
+		bodies = append(bodies, string(body))
+	}
+
+	if err := <-failures; err != nil {
+		t.Fatalf("Expected no errors, got %v", err)
+	}
+
+	expected := []string{
+		"{\"a\": \"ok\", \"b\": \"ok\"}",
+		"{\"a\": \"`bad\", \"b\": \"`bad\"}",
+		"{\"a\": \"fine\", \"b\": \"fine\"}",
+	}
+	if !reflect.DeepEqual(bodies, expected) {
+		t.Fatalf("Expected %v, got %v", expected, bodies)
+	}
+}
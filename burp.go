@@ -0,0 +1,92 @@
+package httpfuzz
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+)
+
+// BurpItems is the XML file Burp Suite writes when you use "Save selected items".
+type BurpItems struct {
+	XMLName xml.Name    `xml:"items"`
+	Items   []*BurpItem `xml:"item"`
+}
+
+// BurpItem is a request saved from Burp Suite, along with where it was sent.
+type BurpItem struct {
+	URL      string       `xml:"url"`
+	Host     string       `xml:"host"`
+	Port     string       `xml:"port"`
+	Protocol string       `xml:"protocol"`
+	Method   string       `xml:"method"`
+	Request  *BurpMessage `xml:"request"`
+}
+
+// BurpMessage is a raw HTTP message, base64 encoded unless the user turned that off when saving.
+type BurpMessage struct {
+	Base64 bool   `xml:"base64,attr"`
+	Data   string `xml:",chardata"`
+}
+
+// Bytes decodes the raw HTTP message.
+func (m *BurpMessage) Bytes() ([]byte, error) {
+	if !m.Base64 {
+		return []byte(m.Data), nil
+	}
+	return base64.StdEncoding.DecodeString(m.Data)
+}
+
+// SeedsFromBurpXML turns every item saved from Burp Suite into a seed.
+// Each request is sent to the host, port and protocol it was saved with, so it doesn't need --https.
+func SeedsFromBurpXML(reader io.Reader) ([]*Seed, error) {
+	items := &BurpItems{}
+	err := xml.NewDecoder(reader).Decode(items)
+	if err != nil {
+		return nil, err
+	}
+
+	seeds := []*Seed{}
+	for i, item := range items.Items {
+		req, err := RequestFromBurpItem(item)
+		if err != nil {
+			return nil, fmt.Errorf("Burp item %d: %v", i, err)
+		}
+
+		seeds = append(seeds, &Seed{
+			Name:    fmt.Sprintf("Burp item %d: %s %s", i, item.Method, item.URL),
+			Request: req,
+		})
+	}
+	return seeds, nil
+}
+
+// RequestFromBurpItem parses the raw request saved in a Burp Suite item.
+// The request keeps its Host header, but is sent to the item's host and port.
+func RequestFromBurpItem(item *BurpItem) (*Request, error) {
+	if item.Request == nil {
+		return nil, fmt.Errorf("item has no request")
+	}
+
+	rawRequest, err := item.Request.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := requestFromBytes(rawRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	req.URL.Scheme = item.Protocol
+	req.URL.Host = item.Host
+	if !isDefaultPort(item.Protocol, item.Port) {
+		req.URL.Host = net.JoinHostPort(item.Host, item.Port)
+	}
+	return req, nil
+}
+
+func isDefaultPort(scheme, port string) bool {
+	return port == "" || (scheme == "http" && port == "80") || (scheme == "https" && port == "443")
+}
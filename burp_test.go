@@ -0,0 +1,115 @@
+package httpfuzz
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func testBurpXML(items ...string) string {
+	return `<?xml version="1.0"?>
+<!DOCTYPE items [
+<!ELEMENT items (item*)>
+<!ATTLIST items burpVersion CDATA "">
+]>
+<items burpVersion="2021.8.2" exportTime="Fri Jan 01 00:00:00 UTC 2021">` + strings.Join(items, "\n") + `</items>`
+}
+
+func testBurpItem(protocol, host, port, rawRequest string, encode bool) string {
+	request := rawRequest
+	if encode {
+		request = base64.StdEncoding.EncodeToString([]byte(rawRequest))
+	}
+
+	return fmt.Sprintf(`<item>
+    <time>Fri Jan 01 00:00:00 UTC 2021</time>
+    <url><![CDATA[%s://%s:%s/]]></url>
+    <host ip="127.0.0.1">%s</host>
+    <port>%s</port>
+    <protocol>%s</protocol>
+    <method><![CDATA[POST]]></method>
+    <request base64="%t"><![CDATA[%s]]></request>
+    <status>200</status>
+  </item>`, protocol, host, port, host, port, protocol, encode, request)
+}
+
+func TestSeedsFromBurpXMLPreservesTarget(t *testing.T) {
+	rawRequest := "POST /login?next=%2F HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Length: 2\r\n\r\n{\"user\": \"`admin`\"}"
+	xml := testBurpXML(
+		testBurpItem("https", "internal.example.com", "8443", rawRequest, true),
+		testBurpItem("http", "example.com", "80", "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n", false),
+	)
+
+	seeds, err := SeedsFromBurpXML(strings.NewReader(xml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seeds) != 2 {
+		t.Fatalf("Expected 2 seeds, got %d", len(seeds))
+	}
+
+	login := seeds[0].Request
+	if login.URL.String() != "https://internal.example.com:8443/login?next=%2F" {
+		t.Fatalf("Expected the saved protocol, host and port, got %s", login.URL)
+	}
+
+	if login.Host != "example.com" {
+		t.Fatalf("Expected the Host header to be kept, got %s", login.Host)
+	}
+
+	body, err := ioutil.ReadAll(login.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "{\"user\": \"`admin`\"}" || login.ContentLength != int64(len(body)) {
+		t.Fatalf("Expected the whole body regardless of Content-Length, got %s", body)
+	}
+
+	index := seeds[1].Request
+	if index.URL.String() != "http://example.com/" {
+		t.Fatalf("Expected the default port to be left out, got %s", index.URL)
+	}
+
+	clone, err := login.CloneBody(login.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if clone.URL.Host != "internal.example.com:8443" {
+		t.Fatalf("Expected clones to be sent to the saved host, got %s", clone.URL.Host)
+	}
+}
+
+func TestSeedsFromBurpXMLKeepsBodiesWithBlankLines(t *testing.T) {
+	// Burp saves requests with CRLF line endings, but bodies keep their own.
+	body := "{\n  \"user\": \"`admin`\",\n\n  \"password\": \"`hunter2`\"\n}"
+	rawRequest := "POST /login HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Length: 2\r\n\r\n" + body
+	xml := testBurpXML(testBurpItem("https", "example.com", "443", rawRequest, true))
+
+	seeds, err := SeedsFromBurpXML(strings.NewReader(xml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	login := seeds[0].Request
+	saved, err := ioutil.ReadAll(login.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(saved) != body || login.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected the whole body after the CRLF headers, got %q", saved)
+	}
+}
+
+func TestSeedsFromBurpXMLRejectsBadRequests(t *testing.T) {
+	xml := testBurpXML(testBurpItem("http", "example.com", "80", "not a request", true))
+	_, err := SeedsFromBurpXML(strings.NewReader(xml))
+	if err == nil || !strings.HasPrefix(err.Error(), "Burp item 0:") {
+		t.Fatalf("Expected an error naming the item, got %v", err)
+	}
+}
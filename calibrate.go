@@ -0,0 +1,321 @@
+package httpfuzz
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+)
+
+// DefaultCalibrationPayloads is the number of junk payloads sent to each location when Config.CalibrationPayloads isn't set.
+const DefaultCalibrationPayloads = 3
+
+// MaxCalibrationPayloads is the most junk payloads Calibrate will send to each location.
+const MaxCalibrationPayloads = 100
+
+// maxJunkLength caps the length of junk payloads so calibrating with lots of samples doesn't send huge requests.
+const maxJunkLength = 256
+
+// statusCodeDeviation is added to the deviation score of a result whose status code doesn't match the baseline.
+const statusCodeDeviation = 10
+
+// Stat is the mean and standard deviation of a measurement.
+type Stat struct {
+	Mean   float64
+	StdDev float64
+}
+
+func newStat(samples []float64) Stat {
+	if len(samples) == 0 {
+		return Stat{}
+	}
+
+	var sum float64
+	for _, sample := range samples {
+		sum += sample
+	}
+	mean := sum / float64(len(samples))
+
+	var squares float64
+	for _, sample := range samples {
+		squares += (sample - mean) * (sample - mean)
+	}
+	return Stat{Mean: mean, StdDev: math.Sqrt(squares / float64(len(samples)))}
+}
+
+// distance returns how many standard deviations a value is from the mean.
+// The standard deviation is never taken to be less than tolerance, so a location that always responds the same way doesn't turn every byte of difference into an anomaly.
+func (s Stat) distance(value, tolerance float64) float64 {
+	spread := math.Max(s.StdDev, tolerance)
+	return math.Abs(value-s.Mean) / spread
+}
+
+// Baseline describes what normal responses look like for an injection location.
+// It is measured before fuzzing by sending the unmodified seed and junk payloads.
+// The baseline for the unmodified seed has an empty Location and FieldName.
+type Baseline struct {
+	Location   string
+	FieldName  string
+	StatusCode int
+	BodySize   Stat
+	Words      Stat
+	Time       Stat
+	Samples    int
+}
+
+func newBaseline(location, fieldName string, results []*Result) *Baseline {
+	statusCodes := map[int]int{}
+	sizes, words, times := []float64{}, []float64{}, []float64{}
+	for _, result := range results {
+		statusCodes[result.Response.StatusCode]++
+		sizes = append(sizes, float64(result.BodySize))
+		words = append(words, float64(result.Words))
+		times = append(times, float64(result.TimeElapsed.Milliseconds()))
+	}
+
+	// The most common status code is the normal one.
+	baseline := &Baseline{
+		Location:  location,
+		FieldName: fieldName,
+		BodySize:  newStat(sizes),
+		Words:     newStat(words),
+		Time:      newStat(times),
+		Samples:   len(results),
+	}
+	for statusCode, count := range statusCodes {
+		if count > statusCodes[baseline.StatusCode] || (count == statusCodes[baseline.StatusCode] && statusCode < baseline.StatusCode) {
+			baseline.StatusCode = statusCode
+		}
+	}
+	return baseline
+}
+
+// Deviation scores how far a result strays from the baseline.
+// It is the largest distance of the body size, word count or response time from the baseline in standard deviations, plus 10 if the status code is different.
+// Results without a response don't have a score.
+func (b *Baseline) Deviation(result *Result) float64 {
+	if result.Response == nil {
+		return 0
+	}
+
+	// Sizes within 5% and times within 50% of normal are treated as noise.
+	score := math.Max(
+		b.BodySize.distance(float64(result.BodySize), math.Max(1, b.BodySize.Mean*0.05)),
+		b.Words.distance(float64(result.Words), math.Max(1, b.Words.Mean*0.05)),
+	)
+	score = math.Max(score, b.Time.distance(float64(result.TimeElapsed.Milliseconds()), math.Max(100, b.Time.Mean*0.5)))
+
+	if result.Response.StatusCode != b.StatusCode {
+		score += statusCodeDeviation
+	}
+	return score
+}
+
+func (b *Baseline) String() string {
+	return fmt.Sprintf("[%d] %.0f±%.0f bytes, %.0f±%.0f words, %.0f±%.0fms over %d requests", b.StatusCode, b.BodySize.Mean, b.BodySize.StdDev, b.Words.Mean, b.Words.StdDev, b.Time.Mean, b.Time.StdDev, b.Samples)
+}
+
+func baselineKey(location, fieldName string) string {
+	return location + "\x00" + fieldName
+}
+
+// Calibrate establishes a baseline for every injection location before fuzzing.
+// It sends the unmodified seed, then CalibrationPayloads random junk payloads to each location, and records what the responses looked like.
+// Once calibrated, every Result carries the Baseline for its location and its Deviation from it.
+// Cancelling the context stops calibration and cancels the request in flight.
+func (f *Fuzzer) Calibrate(ctx context.Context) ([]*Baseline, error) {
+	samples := f.CalibrationPayloads
+	if samples < 1 {
+		samples = DefaultCalibrationPayloads
+	}
+
+	if samples > MaxCalibrationPayloads {
+		return nil, fmt.Errorf("can't send more than %d calibration payloads to each location", MaxCalibrationPayloads)
+	}
+
+	seed, err := f.seeds()[0].Request.CloneBody(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = seed.RemoveDelimiters(f.TargetDelimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []*Job{}
+	for i := 0; i < samples; i++ {
+		jobs = append(jobs, &Job{Request: seed})
+	}
+
+	for i := 0; i < samples; i++ {
+		// Vary the length of the junk so the baseline captures responses that reflect the payload.
+		locationJobs, err := f.calibrationJobs(junkPayload(junkLength(i)))
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, locationJobs...)
+	}
+
+	keys := []string{}
+	grouped := map[string][]*Result{}
+	for _, job := range jobs {
+		result, err := f.calibrate(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+
+		key := baselineKey(job.Location, job.FieldName)
+		if _, found := grouped[key]; !found {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], result)
+	}
+
+	baselines := []*Baseline{}
+	f.baselines = map[string]*Baseline{}
+	for _, key := range keys {
+		results := grouped[key]
+		baseline := newBaseline(results[0].Location, results[0].FieldName, results)
+		f.baselines[key] = baseline
+		baselines = append(baselines, baseline)
+	}
+	return baselines, nil
+}
+
+// calibrate sends a calibration request and measures the response.
+func (f *Fuzzer) calibrate(ctx context.Context, job *Job) (*Result, error) {
+	req, err := job.Request.CloneBody(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Scheme == "" {
+		req.URL.Scheme = f.URLScheme
+	}
+
+	response, _, timeElapsed, err := f.send(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending calibration request: %v", err)
+	}
+	defer func() {
+		io.Copy(ioutil.Discard, response.Body)
+		response.Body.Close()
+	}()
+
+	result := &Result{
+		Response:    response,
+		Location:    job.Location,
+		FieldName:   job.FieldName,
+		TimeElapsed: timeElapsed,
+	}
+	return result, measureResponse(result)
+}
+
+// calibrationJobs places a payload in every location the fuzzer will target, grouped the same way the attack mode groups them.
+func (f *Fuzzer) calibrationJobs(payload string) ([]*Job, error) {
+	var targets []*Target
+	switch f.AttackMode {
+	case PitchforkAttack, ClusterBombAttack:
+		targets = f.Targets
+	case BatteringRamAttack:
+		var err error
+		targets, err = f.batteringRamTargets(f.seeds()[0])
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return f.sniperCalibrationJobs(payload)
+	}
+
+	if len(targets) == 0 {
+		return []*Job{}, nil
+	}
+
+	payloads := make([]string, len(targets))
+	for i := range payloads {
+		payloads[i] = payload
+	}
+
+	req, err := injectTargets(f.seeds()[0].Request, targets, payloads, f.TargetDelimiter)
+	if err != nil {
+		return nil, err
+	}
+	return []*Job{multiTargetJob(req, targets, payloads)}, nil
+}
+
+// sniperCalibrationJobs places a payload in each wordlist location one at a time, the same way generateSniper does.
+// File uploads aren't calibrated since they don't use the wordlist.
+func (f *Fuzzer) sniperCalibrationJobs(payload string) ([]*Job, error) {
+	jobs := make(chan *Job)
+	errors := make(chan error)
+	seed := f.seeds()[0]
+	go func() {
+		state := &fuzzerState{
+			PayloadWord:         payload,
+			Seed:                seed.Request,
+			BodyTargetDelimiter: f.TargetDelimiter,
+		}
+		fuzzHeaders(state, withSeedTargets(f.TargetHeaders, seed.TargetHeaders), jobs, errors)
+		fuzzURLParams(state, withSeedTargets(f.TargetParams, seed.TargetParams), jobs, errors)
+		fuzzURLPathArgs(state, withSeedTargets(f.TargetPathArgs, seed.TargetPathArgs), jobs, errors)
+
+		empty := []string{}
+		if f.FuzzDirectory {
+			fuzzDirectoryRoot(state, empty, jobs, errors)
+		}
+
+		if seed.Request.IsMultipartForm() {
+			fuzzMultipartFormField(state, f.TargetMultipartFieldNames, jobs, errors)
+		} else {
+			fuzzTextBodyWithDelimiters(state, empty, jobs, errors)
+		}
+		close(jobs)
+	}()
+
+	collected := []*Job{}
+	var firstErr error
+	for {
+		select {
+		case job, ok := <-jobs:
+			if !ok {
+				return collected, firstErr
+			}
+			collected = append(collected, job)
+		case err := <-errors:
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+}
+
+// baselineFor returns the baseline for a location, falling back to the unmodified seed's baseline.
+// It returns nil if the fuzzer hasn't been calibrated.
+func (f *Fuzzer) baselineFor(location, fieldName string) *Baseline {
+	if baseline, found := f.baselines[baselineKey(location, fieldName)]; found {
+		return baseline
+	}
+	return f.baselines[baselineKey("", "")]
+}
+
+// junkLength is the length of the i-th junk payload, growing by 8 bytes each time up to maxJunkLength.
+func junkLength(i int) int {
+	length := 8 * (i + 1)
+	if length > maxJunkLength {
+		return maxJunkLength
+	}
+	return length
+}
+
+const junkAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// junkPayload returns a random alphanumeric string that's unlikely to mean anything to the target.
+func junkPayload(length int) string {
+	junk := make([]byte, length)
+	for i := range junk {
+		junk[i] = junkAlphabet[rand.Intn(len(junkAlphabet))]
+	}
+	return string(junk)
+}
@@ -0,0 +1,121 @@
+package httpfuzz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCalibratedFuzzerOnlyReportsAnomalies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Role") == "admin" {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "Traceback (most recent call last): role lookup failed for admin")
+			return
+		}
+		fmt.Fprint(w, "Welcome")
+	}))
+	defer server.Close()
+
+	wordlist, err := os.Open("testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	collector := &resultCollector{}
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	config := &Config{
+		TargetHeaders:    []string{"X-Role"},
+		Wordlist:         &Wordlist{File: wordlist},
+		Seed:             &Request{request},
+		Client:           &Client{&http.Client{}},
+		Plugins:          testBroker(collector),
+		AnomalyThreshold: 3,
+		TargetDelimiter:  '`',
+		Logger:           testLogger(t),
+		URLScheme:        "http",
+	}
+	fuzzer := &Fuzzer{config}
+	baselines, err := fuzzer.Calibrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One baseline for the unmodified seed and one for the header.
+	if len(baselines) != 2 {
+		t.Fatalf("Expected 2 baselines, got %d", len(baselines))
+	}
+
+	for _, baseline := range baselines {
+		if baseline.StatusCode != http.StatusOK || baseline.Samples != DefaultCalibrationPayloads {
+			t.Fatalf("Unexpected baseline %s", baseline)
+		}
+	}
+
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	fuzzer.ProcessRequests(context.Background(), requests)
+
+	if len(collector.results) != 1 {
+		t.Fatalf("Expected 1 anomaly, got %d", len(collector.results))
+	}
+
+	anomaly := collector.results[0]
+	if anomaly.Payload != "admin" {
+		t.Fatalf("Expected admin to be the anomaly, got %s", anomaly.Payload)
+	}
+
+	if anomaly.Baseline == nil || anomaly.Baseline.Location != headerLocation || anomaly.Deviation < config.AnomalyThreshold {
+		t.Fatalf("Expected anomaly to carry its header baseline and deviation, got %+v", *anomaly)
+	}
+}
+
+func TestJunkLengthIsBounded(t *testing.T) {
+	if junkLength(0) != 8 || junkLength(1) != 16 {
+		t.Fatalf("Expected junk to grow by 8 bytes, got %d and %d", junkLength(0), junkLength(1))
+	}
+
+	if junkLength(MaxCalibrationPayloads-1) != maxJunkLength {
+		t.Fatalf("Expected junk to be capped at %d bytes, got %d", maxJunkLength, junkLength(MaxCalibrationPayloads-1))
+	}
+}
+
+func TestCalibrateStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hang like an unresponsive target until the run is cancelled.
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	config := &Config{
+		TargetHeaders:   []string{"X-Role"},
+		Seed:            &Request{request},
+		Client:          &Client{&http.Client{}},
+		TargetDelimiter: '`',
+		Logger:          testLogger(t),
+		URLScheme:       "http",
+	}
+	fuzzer := &Fuzzer{config}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := fuzzer.Calibrate(ctx)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("Expected an error when calibration is cancelled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected calibration to stop when cancelled")
+	}
+}
@@ -0,0 +1,251 @@
+package httpfuzz
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Generator phases recorded in a Position.
+const (
+	// seedPhase is the start of a seed, before anything has been generated from it.
+	seedPhase = "seed"
+	// filesPhase is where the sniper attack uploads files before it starts on the wordlist.
+	filesPhase = "files"
+	// wordlistPhase is where payloads come from the wordlists.
+	wordlistPhase = "wordlist"
+)
+
+// Position marks where GenerateRequests was in its inputs, so generation can pick up from there.
+// Seed is the index of the seed request being fuzzed.
+// Offsets are the byte offsets of the words being used from each wordlist: the main wordlist for the sniper and battering ram attacks, or each target's wordlist for the pitchfork and cluster bomb attacks.
+// JobID is the ID of the first job generated from this position.
+type Position struct {
+	Seed    int     `json:"seed"`
+	Phase   string  `json:"phase"`
+	Offsets []int64 `json:"offsets,omitempty"`
+	JobID   int     `json:"job_id"`
+}
+
+// Checkpoint records how far a run got.
+// Every job with an ID below Watermark is complete, along with the jobs listed in Completed.
+// Generation resumes from Position, the latest position that no incomplete job comes before.
+type Checkpoint struct {
+	Position  *Position `json:"position,omitempty"`
+	Watermark int       `json:"watermark"`
+	Completed []int     `json:"completed,omitempty"`
+}
+
+// Checkpointer tracks which jobs are complete and periodically saves a Checkpoint to Filename, so an interrupted run can be resumed.
+// A job is complete once its response has been processed, or once it's failed after every attempt and been reported, so one bad request can't hold the watermark back.
+// Jobs cut off by stopping the run are sent again on resume.
+// The same inputs and settings must be used when resuming, since jobs are identified by the order they're generated in.
+type Checkpointer struct {
+	Filename  string
+	Interval  time.Duration
+	mutex     sync.Mutex
+	saving    sync.Mutex
+	positions []*Position
+	watermark int
+	completed map[int]bool
+	resume    *Position
+	lastSaved time.Time
+}
+
+// NewCheckpointer starts tracking a new run.
+func NewCheckpointer(filename string, interval time.Duration) *Checkpointer {
+	return &Checkpointer{
+		Filename:  filename,
+		Interval:  interval,
+		completed: map[int]bool{},
+		lastSaved: time.Now(),
+	}
+}
+
+// ResumeCheckpointer loads the checkpoint saved in a file, so the fuzzer skips the jobs that were already completed.
+func ResumeCheckpointer(filename string, interval time.Duration) (*Checkpointer, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := &Checkpoint{}
+	err = json.Unmarshal(contents, checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpointer := NewCheckpointer(filename, interval)
+	checkpointer.watermark = checkpoint.Watermark
+	for _, id := range checkpoint.Completed {
+		checkpointer.completed[id] = true
+	}
+
+	if checkpoint.Position != nil {
+		checkpointer.resume = checkpoint.Position
+		checkpointer.positions = []*Position{checkpoint.Position}
+	}
+	return checkpointer, nil
+}
+
+// Checkpoint returns a snapshot of the run's progress.
+func (c *Checkpointer) Checkpoint() *Checkpoint {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.checkpoint()
+}
+
+func (c *Checkpointer) checkpoint() *Checkpoint {
+	checkpoint := &Checkpoint{Watermark: c.watermark, Completed: []int{}}
+	if len(c.positions) > 0 {
+		checkpoint.Position = c.positions[0]
+	}
+
+	for id := range c.completed {
+		checkpoint.Completed = append(checkpoint.Completed, id)
+	}
+	sort.Ints(checkpoint.Completed)
+	return checkpoint
+}
+
+// Save writes the checkpoint to Filename.
+// The file is replaced in one step, so a crash while saving leaves the previous checkpoint intact.
+// Only taking the snapshot blocks the workers, not writing it.
+func (c *Checkpointer) Save() error {
+	if c == nil {
+		return nil
+	}
+
+	// Saves are written one at a time so an older snapshot can't replace a newer one.
+	c.saving.Lock()
+	defer c.saving.Unlock()
+
+	c.mutex.Lock()
+	checkpoint := c.checkpoint()
+	c.lastSaved = time.Now()
+	c.mutex.Unlock()
+
+	contents, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	temporary, err := ioutil.TempFile(filepath.Dir(c.Filename), filepath.Base(c.Filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+
+	_, err = temporary.Write(contents)
+	if err != nil {
+		temporary.Close()
+		return err
+	}
+
+	err = temporary.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporary.Name(), c.Filename)
+}
+
+// CompletedJobs returns the number of jobs that are complete.
+func (c *Checkpointer) CompletedJobs() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.watermark + len(c.completed)
+}
+
+// resumePosition is where generation should start, or nil to start from the beginning.
+func (c *Checkpointer) resumePosition() *Position {
+	if c == nil {
+		return nil
+	}
+	return c.resume
+}
+
+// startJobID is the ID of the first job generated from the resume position.
+func (c *Checkpointer) startJobID() int {
+	if c == nil || c.resume == nil {
+		return 0
+	}
+	return c.resume.JobID
+}
+
+// markPosition records that the jobs starting at jobID are generated from a position.
+func (c *Checkpointer) markPosition(position *Position, jobID int) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	position.JobID = jobID
+	c.positions = append(c.positions, position)
+	c.prune()
+}
+
+// isComplete returns true if a job was completed, in this run or before it was resumed.
+func (c *Checkpointer) isComplete(jobID int) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return jobID < c.watermark || c.completed[jobID]
+}
+
+// complete records that a job is complete, and saves a checkpoint if it's been Interval since the last one.
+func (c *Checkpointer) complete(jobID int) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	c.completed[jobID] = true
+	for c.completed[c.watermark] {
+		delete(c.completed, c.watermark)
+		c.watermark++
+	}
+	c.prune()
+	due := time.Since(c.lastSaved) >= c.Interval
+	c.mutex.Unlock()
+
+	if !due {
+		return nil
+	}
+	return c.Save()
+}
+
+// seed is the index of the seed a position is in, or the first seed for a nil position.
+func (p *Position) seed() int {
+	if p == nil {
+		return 0
+	}
+	return p.Seed
+}
+
+// offset is where the wordlist with an index should be streamed from to pick up at this position.
+// It's the start of the wordlist for a nil position or one that isn't in the wordlist phase.
+func (p *Position) offset(index int) int64 {
+	if p == nil || p.Phase != wordlistPhase || index >= len(p.Offsets) {
+		return 0
+	}
+	return p.Offsets[index]
+}
+
+// prune forgets positions that a resumed run would never need to go back to.
+func (c *Checkpointer) prune() {
+	for len(c.positions) > 1 && c.positions[1].JobID <= c.watermark {
+		c.positions = c.positions[1:]
+	}
+}
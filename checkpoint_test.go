@@ -0,0 +1,253 @@
+package httpfuzz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCheckpointerAdvancesWatermarkPastCompletedJobs(t *testing.T) {
+	checkpointer := NewCheckpointer(filepath.Join(t.TempDir(), "checkpoint.json"), 0)
+	checkpointer.markPosition(&Position{Phase: wordlistPhase, Offsets: []int64{0}}, 0)
+	checkpointer.markPosition(&Position{Phase: wordlistPhase, Offsets: []int64{6}}, 2)
+	checkpointer.markPosition(&Position{Phase: wordlistPhase, Offsets: []int64{11}}, 4)
+
+	for _, id := range []int{0, 1, 3} {
+		err := checkpointer.complete(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkpoint := checkpointer.Checkpoint()
+	if checkpoint.Watermark != 2 {
+		t.Fatalf("Expected watermark 2, got %d", checkpoint.Watermark)
+	}
+
+	if len(checkpoint.Completed) != 1 || checkpoint.Completed[0] != 3 {
+		t.Fatalf("Expected completed jobs [3], got %v", checkpoint.Completed)
+	}
+
+	// Job 2 isn't complete, so generation has to pick up from the word it came from.
+	if checkpoint.Position.JobID != 2 || checkpoint.Position.Offsets[0] != 6 {
+		t.Fatalf("Expected to resume from job 2 at offset 6, got job %d at offset %d", checkpoint.Position.JobID, checkpoint.Position.Offsets[0])
+	}
+
+	if checkpointer.CompletedJobs() != 3 {
+		t.Fatalf("Expected 3 completed jobs, got %d", checkpointer.CompletedJobs())
+	}
+}
+
+func TestResumeResendsOnlyIncompleteJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mutex sync.Mutex
+	interrupting := true
+	received := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		if interrupting && r.Header.Get("X-User") == "root" {
+			// Stop the run while this request is in flight, so it and everything after it is left incomplete.
+			mutex.Unlock()
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+		received = append(received, r.Header.Get("X-User")+"|"+r.URL.Query().Get("q"))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	wordlist, err := os.Open("testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	filename := filepath.Join(t.TempDir(), "checkpoint.json")
+	request, _ := http.NewRequest("GET", server.URL+"/?q=x", nil)
+	newFuzzer := func(checkpointer *Checkpointer) *Fuzzer {
+		return &Fuzzer{&Config{
+			TargetHeaders:   []string{"X-User"},
+			TargetParams:    []string{"q"},
+			Wordlist:        &Wordlist{File: wordlist},
+			Seed:            &Request{request},
+			Client:          &Client{&http.Client{}},
+			Plugins:         &PluginBroker{},
+			Checkpoint:      checkpointer,
+			Concurrency:     1,
+			TargetDelimiter: '*',
+			Logger:          testLogger(t),
+			URLScheme:       "http",
+		}}
+	}
+
+	fuzzer := newFuzzer(NewCheckpointer(filename, 0))
+	count, err := fuzzer.RequestCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	fuzzer.ProcessRequests(ctx, requests)
+
+	mutex.Lock()
+	interrupting = false
+	received = []string{}
+	mutex.Unlock()
+
+	checkpointer, err := ResumeCheckpointer(filename, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fuzzer = newFuzzer(checkpointer)
+	count, err = fuzzer.RequestCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 4 {
+		t.Fatalf("Expected 4 remaining requests, got %d", count)
+	}
+
+	requests, _ = fuzzer.GenerateRequests(context.Background())
+	fuzzer.ProcessRequests(context.Background(), requests)
+
+	expected := []string{"root|x", "|root", "guest|x", "|guest"}
+	if !reflect.DeepEqual(received, expected) {
+		t.Fatalf("Expected only the interrupted requests to be resent, got %v", received)
+	}
+
+	if checkpointer.CompletedJobs() != 6 {
+		t.Fatalf("Expected 6 completed jobs, got %d", checkpointer.CompletedJobs())
+	}
+}
+
+func TestFailedJobsDontHoldBackCheckpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-User") == "admin" {
+			// Drop the connection so the first request fails.
+			connection, _, _ := w.(http.Hijacker).Hijack()
+			connection.Close()
+		}
+	}))
+	defer server.Close()
+
+	wordlist, err := os.Open("testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	request, _ := http.NewRequest("GET", server.URL+"/?q=x", nil)
+	checkpointer := NewCheckpointer(filepath.Join(t.TempDir(), "checkpoint.json"), 0)
+	fuzzer := &Fuzzer{&Config{
+		TargetHeaders:   []string{"X-User"},
+		TargetParams:    []string{"q"},
+		Wordlist:        &Wordlist{File: wordlist},
+		Seed:            &Request{request},
+		Client:          &Client{&http.Client{}},
+		Plugins:         &PluginBroker{},
+		Checkpoint:      checkpointer,
+		TargetDelimiter: '*',
+		Logger:          testLogger(t),
+		URLScheme:       "http",
+	}}
+
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	fuzzer.ProcessRequests(context.Background(), requests)
+
+	checkpoint := checkpointer.Checkpoint()
+	if checkpoint.Watermark != 6 || len(checkpoint.Completed) != 0 {
+		t.Fatalf("Expected the watermark to pass the failed job, got watermark %d and completed jobs %v", checkpoint.Watermark, checkpoint.Completed)
+	}
+
+	if len(checkpointer.positions) != 1 {
+		t.Fatalf("Expected positions before the watermark to be forgotten, got %d", len(checkpointer.positions))
+	}
+}
+
+func TestClusterBombResumesFromCheckpoint(t *testing.T) {
+	request, _ := http.NewRequest("GET", "/login", nil)
+	targets := []*Target{
+		testTarget(t, urlParamLocation, "user", "testdata/usernames.txt"),
+		testTarget(t, headerLocation, "X-Password", "testdata/passwords.txt"),
+	}
+	filename := filepath.Join(t.TempDir(), "checkpoint.json")
+	newFuzzer := func(checkpointer *Checkpointer) *Fuzzer {
+		return &Fuzzer{&Config{
+			AttackMode:      ClusterBombAttack,
+			Targets:         targets,
+			Seed:            &Request{request},
+			Checkpoint:      checkpointer,
+			TargetDelimiter: '`',
+			Logger:          testLogger(t),
+			URLScheme:       "http",
+		}}
+	}
+
+	// Complete a few jobs out of order, as concurrent workers would.
+	checkpointer := NewCheckpointer(filename, 0)
+	combinations := map[int]string{}
+	requests, _ := newFuzzer(checkpointer).GenerateRequests(context.Background())
+	for job := range requests {
+		combinations[job.ID] = job.Payload
+		if job.ID < 5 || job.ID == 6 {
+			err := checkpointer.complete(job.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	err := checkpointer.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkpointer, err = ResumeCheckpointer(filename, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fuzzer := newFuzzer(checkpointer)
+	count, err := fuzzer.RequestCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 6 {
+		t.Fatalf("Expected 6 remaining requests, got %d", count)
+	}
+
+	resumed := 0
+	requests, _ = fuzzer.GenerateRequests(context.Background())
+	for job := range requests {
+		if job.ID < 5 || job.ID == 6 {
+			t.Fatalf("Completed job %d was generated again", job.ID)
+		}
+
+		if job.Payload != combinations[job.ID] {
+			t.Fatalf("Expected job %d to be %s, got %s", job.ID, combinations[job.ID], job.Payload)
+		}
+		resumed++
+	}
+
+	if resumed != count {
+		t.Fatalf("Expected %d resumed jobs, got %d", count, resumed)
+	}
+}
+
+func TestResumeCheckpointerRejectsMissingFile(t *testing.T) {
+	_, err := ResumeCheckpointer(filepath.Join(t.TempDir(), "missing.json"), 0)
+	if err == nil {
+		t.Fatalf("Expected error resuming from a missing checkpoint")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+// newHTTPClient builds the client requests are sent with from the flags declared by clientFlags.
+func newHTTPClient(c *cli.Context, maxIdleConnsPerHost int) (*http.Client, error) {
+	rootCAs, _ := x509.SystemCertPool()
+	if rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if proxyCACertFilename := c.String("proxy-ca-pem"); proxyCACertFilename != "" {
+		proxyCACertFile, err := os.Open(proxyCACertFilename)
+		if err != nil {
+			return nil, err
+		}
+		defer proxyCACertFile.Close()
+
+		certs, err := ioutil.ReadAll(proxyCACertFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok := rootCAs.AppendCertsFromPEM(certs); !ok {
+			return nil, fmt.Errorf("failed to trust custom CA certs from %s", proxyCACertFilename)
+		}
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: c.Bool("skip-cert-verify"),
+			RootCAs:            rootCAs,
+		},
+		MaxIdleConnsPerHost: maxIdleConnsPerHost,
+	}
+
+	if proxyURL := c.String("proxy-url"); proxyURL != "" {
+		proxy, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, err
+		}
+
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return &http.Client{
+		Transport: transport,
+	}, nil
+}
+
+// clientFlags declares the flags read by newHTTPClient.
+func clientFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:     "skip-cert-verify",
+			Required: false,
+			Value:    false,
+			Usage:    "skip verifying SSL certificate when making requests",
+		},
+		&cli.StringFlag{
+			Name:     "proxy-url",
+			Required: false,
+			Usage:    "HTTP proxy to send requests through",
+		},
+		&cli.StringFlag{
+			Name:     "proxy-ca-pem",
+			Required: false,
+			Usage:    "PEM encoded CA Certificate for TLS requests through a proxy",
+		},
+	}
+}
@@ -1,97 +1,137 @@
 package main
 
 import (
-	"crypto/tls"
-	"crypto/x509"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
 	"time"
 
 	"github.com/joncooperworks/httpfuzz"
+	"github.com/joncooperworks/httpfuzz/sqlitestore"
 	"github.com/urfave/cli/v2"
 )
 
 func actionHTTPFuzz(c *cli.Context) error {
-	seedRequest, err := httpfuzz.RequestFromFile(c.String("seed-request"))
+	seeds, err := loadSeeds(c)
 	if err != nil {
 		return err
 	}
 
 	targetPathArgs := c.StringSlice("target-path-arg")
-	for _, arg := range targetPathArgs {
-		if !seedRequest.HasPathArgument(arg) {
-			return fmt.Errorf("seed request does not have URL path arg '%s'", arg)
+	for _, seed := range seeds {
+		for _, arg := range targetPathArgs {
+			if !seed.Request.HasPathArgument(arg) {
+				return fmt.Errorf("seed request %s does not have URL path arg '%s'", seed.Name, arg)
+			}
 		}
 	}
 
-	rootCAs, _ := x509.SystemCertPool()
-	if rootCAs == nil {
-		rootCAs = x509.NewCertPool()
+	if payloads := c.Int("calibration-payloads"); payloads < 1 || payloads > httpfuzz.MaxCalibrationPayloads {
+		return fmt.Errorf("calibration-payloads must be between 1 and %d", httpfuzz.MaxCalibrationPayloads)
+	}
+
+	concurrency := c.Int("concurrency")
+	if concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1")
+	}
+
+	// Keep a connection around for every worker so they aren't constantly reconnecting.
+	httpClient, err := newHTTPClient(c, concurrency)
+	if err != nil {
+		return err
+	}
+	logger := log.New(os.Stdout, "httpfuzz: ", log.Ldate|log.Ltime|log.Lshortfile)
+
+	var urlScheme string
+	if c.Bool("https") {
+		urlScheme = "https"
+	} else {
+		urlScheme = "http"
 	}
-	if proxyCACertFilename := c.String("proxy-ca-pem"); proxyCACertFilename != "" {
-		proxyCACertFile, err := os.Open(proxyCACertFilename)
+
+	plugins, err := httpfuzz.LoadPlugins(logger, c.StringSlice("post-request"))
+	if err != nil {
+		return err
+	}
+
+	if outputFilename := c.String("output"); outputFilename != "" {
+		if format := c.String("output-format"); format != "jsonl" {
+			return fmt.Errorf("unsupported output format '%s'", format)
+		}
+
+		outputFile, err := os.Create(outputFilename)
 		if err != nil {
 			return err
 		}
-		defer proxyCACertFile.Close()
+		defer outputFile.Close()
 
-		certs, err := ioutil.ReadAll(proxyCACertFile)
+		plugins.Register(&httpfuzz.JSONLinesWriter{Writer: outputFile, Logger: logger})
+	}
+
+	if reportFilename := c.String("html-report"); reportFilename != "" {
+		reportFile, err := os.Create(reportFilename)
 		if err != nil {
 			return err
 		}
+		defer reportFile.Close()
 
-		if ok := rootCAs.AppendCertsFromPEM(certs); !ok {
-			return fmt.Errorf("failed to trust custom CA certs from %s", proxyCACertFilename)
-		}
+		plugins.Register(&httpfuzz.HTMLReport{Writer: reportFile, Logger: logger})
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: c.Bool("skip-cert-verify"),
-			RootCAs:            rootCAs,
-		},
+	if harFilename := c.String("har-output"); harFilename != "" {
+		harFile, err := os.Create(harFilename)
+		if err != nil {
+			return err
+		}
+		defer harFile.Close()
+
+		plugins.Register(&httpfuzz.HARWriter{Writer: harFile, Logger: logger})
 	}
 
-	if proxyURL := c.String("proxy-url"); proxyURL != "" {
-		proxy, err := url.Parse(proxyURL)
+	if dbFilename := c.String("db"); dbFilename != "" {
+		store, err := sqlitestore.Open(dbFilename, logger)
 		if err != nil {
 			return err
 		}
+		defer store.Close()
 
-		transport.Proxy = http.ProxyURL(proxy)
+		plugins.Register(store)
 	}
 
-	httpClient := &http.Client{
-		Transport: transport,
-	}
-	logger := log.New(os.Stdout, "httpfuzz: ", log.Ldate|log.Ltime|log.Lshortfile)
+	var junit *httpfuzz.JUnitWriter
+	if junitFilename := c.String("junit-output"); junitFilename != "" {
+		junitFile, err := os.Create(junitFilename)
+		if err != nil {
+			return err
+		}
+		defer junitFile.Close()
 
-	var urlScheme string
-	if c.Bool("https") {
-		urlScheme = "https"
-	} else {
-		urlScheme = "http"
-	}
+		assertions, err := matcherFromFlags(c, "fail")
+		if err != nil {
+			return err
+		}
 
-	plugins, err := httpfuzz.LoadPlugins(logger, c.StringSlice("post-request"))
-	if err != nil {
-		return err
+		junit = &httpfuzz.JUnitWriter{Writer: junitFile, Logger: logger, Assertions: assertions}
+		plugins.RegisterUnfiltered(junit)
 	}
 
 	delimiter := []byte(c.String("target-delimiter"))[0]
 
 	multipartFileKeys := c.StringSlice("multipart-file-name")
 	multipartFormFields := c.StringSlice("multipart-form-name")
-	if !seedRequest.IsMultipartForm() {
-		// Validate that the request body is properly delimitered
-		_, err = seedRequest.BodyTargetCount(delimiter)
-		if err != nil {
-			return err
+	for _, seed := range seeds {
+		if !seed.Request.IsMultipartForm() {
+			// Validate that the request body is properly delimitered
+			_, err = seed.Request.BodyTargetCount(delimiter)
+			if err != nil {
+				return fmt.Errorf("seed request %s: %v", seed.Name, err)
+			}
 		}
 	}
 
@@ -128,6 +168,77 @@ func actionHTTPFuzz(c *cli.Context) error {
 		wordlist = &httpfuzz.Wordlist{File: wordlistFile}
 	}
 
+	attackMode, err := httpfuzz.ParseAttackMode(c.String("attack-mode"))
+	if err != nil {
+		return err
+	}
+
+	targets := []*httpfuzz.Target{}
+	for _, spec := range c.StringSlice("target-wordlist") {
+		target, err := httpfuzz.ParseTarget(spec)
+		if err != nil {
+			return err
+		}
+		defer target.Wordlist.File.Close()
+
+		for _, seed := range seeds {
+			if !seed.Request.HasTarget(target) {
+				return fmt.Errorf("seed request %s does not have %s '%s'", seed.Name, target.Location, target.FieldName)
+			}
+		}
+		targets = append(targets, target)
+	}
+
+	if (attackMode == httpfuzz.PitchforkAttack || attackMode == httpfuzz.ClusterBombAttack) && len(targets) == 0 {
+		return fmt.Errorf("%s attack requires at least one --target-wordlist", attackMode)
+	}
+
+	var throttle *httpfuzz.Throttle
+	if c.Bool("adaptive-throttle") {
+		throttle = httpfuzz.NewThrottle(logger)
+		throttle.StatusCodes = c.IntSlice("throttle-status")
+		throttle.ErrorRateThreshold = c.Float64("throttle-error-rate")
+		throttle.SlowResponse = time.Duration(c.Int("throttle-slow-ms")) * time.Millisecond
+		throttle.MaxDelay = time.Duration(c.Int("throttle-max-delay-ms")) * time.Millisecond
+		throttle.MaxPause = time.Duration(c.Int("throttle-max-pause-s")) * time.Second
+	}
+
+	var retry *httpfuzz.RetryPolicy
+	if attempts := c.Int("max-attempts"); attempts > 1 {
+		retry = &httpfuzz.RetryPolicy{
+			MaxAttempts: attempts,
+			BaseDelay:   time.Duration(c.Int("retry-delay-ms")) * time.Millisecond,
+			MaxDelay:    time.Duration(c.Int("retry-max-delay-ms")) * time.Millisecond,
+			StatusCodes: c.IntSlice("retry-status"),
+		}
+	}
+
+	match, err := matcherFromFlags(c, "match")
+	if err != nil {
+		return err
+	}
+
+	filter, err := matcherFromFlags(c, "filter")
+	if err != nil {
+		return err
+	}
+
+	findingRules, err := matcherFromFlags(c, "finding")
+	if err != nil {
+		return err
+	}
+
+	var findings *httpfuzz.Findings
+	sarifFilename := c.String("sarif-output")
+	if sarifFilename != "" {
+		findings = &httpfuzz.Findings{}
+	}
+
+	checkpoint, err := checkpointerFromFlags(c)
+	if err != nil {
+		return err
+	}
+
 	client := &httpfuzz.Client{Client: httpClient}
 	config := &httpfuzz.Config{
 		TargetHeaders:             c.StringSlice("target-header"),
@@ -143,12 +254,26 @@ func actionHTTPFuzz(c *cli.Context) error {
 		TargetPathArgs:            targetPathArgs,
 		Wordlist:                  wordlist,
 		Client:                    client,
-		Seed:                      seedRequest,
+		Seed:                      seeds[0].Request,
+		Seeds:                     seeds,
 		TargetDelimiter:           delimiter,
 		Logger:                    logger,
 		RequestDelay:              time.Duration(c.Int("delay-ms")) * time.Millisecond,
+		Concurrency:               concurrency,
+		RateLimiter:               httpfuzz.NewRateLimiter(c.Float64("rate"), c.Int("burst")),
+		Throttle:                  throttle,
+		Retry:                     retry,
+		Match:                     match,
+		Filter:                    filter,
+		CalibrationPayloads:       c.Int("calibration-payloads"),
+		AnomalyThreshold:          c.Float64("anomaly-threshold"),
+		FindingRules:              findingRules,
+		Findings:                  findings,
+		Checkpoint:                checkpoint,
 		URLScheme:                 urlScheme,
 		Plugins:                   plugins,
+		AttackMode:                attackMode,
+		Targets:                   targets,
 	}
 
 	fuzzer := &httpfuzz.Fuzzer{Config: config}
@@ -157,6 +282,11 @@ func actionHTTPFuzz(c *cli.Context) error {
 		return err
 	}
 
+	if requestCount == 0 && c.Bool("resume") {
+		logger.Printf("Every request in %s has already been sent", c.String("checkpoint"))
+		return nil
+	}
+
 	if requestCount == 0 {
 		return fmt.Errorf("no requests to be sent")
 	}
@@ -164,45 +294,412 @@ func actionHTTPFuzz(c *cli.Context) error {
 	logger.Printf("Sending %d requests", requestCount)
 
 	if !c.Bool("count-only") {
-		fuzzer.WaitFor(requestCount)
-		requests, errors := fuzzer.GenerateRequests()
-		// Listen for errors generating requests in the background so we don't block forever waiting on requests that never come.
-		go func(errors <-chan error, logger *log.Logger) {
-			select {
-			case err := <-errors:
-				if err != nil {
-					logger.Fatal(err)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go handleInterrupts(cancel, logger)
+
+		if c.Bool("calibrate") {
+			if len(seeds) > 1 {
+				return fmt.Errorf("--calibrate only works with a single seed request, but %d were loaded", len(seeds))
+			}
+
+			baselines, err := fuzzer.Calibrate(ctx)
+			if ctx.Err() != nil {
+				// Nothing has been sent to the outputs yet, so there's nothing to finish up.
+				return cli.Exit("interrupted during calibration", 130)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			for _, baseline := range baselines {
+				if baseline.Location == "" {
+					logger.Printf("Baseline for unmodified seed: %s", baseline)
+					continue
 				}
+				logger.Printf("Baseline for %s field \"%s\": %s", baseline.Location, baseline.FieldName, baseline)
+			}
+		}
+
+		progress := startProgress(c, fuzzer, requestCount, logger)
+		requests, errors := fuzzer.GenerateRequests(ctx)
+		// Listen for errors generating requests in the background so the generator isn't blocked sending one.
+		// The first error stops the run the same way Ctrl-C does, so results received so far are still flushed.
+		var generationErr error
+		generationDone := make(chan struct{})
+		go func() {
+			defer close(generationDone)
+			for err := range errors {
+				if generationErr == nil {
+					generationErr = err
+					logger.Printf("Error generating requests, finishing up: %v", err)
+					cancel()
+				}
+			}
+		}()
+
+		fuzzer.ProcessRequests(ctx, requests)
+		<-generationDone
+		progress.Stop()
+		summary := fuzzer.Summary()
+		if ctx.Err() != nil {
+			logger.Printf("Stopped after sending %d of %d requests: %s.", summary.Completed(), requestCount, summary)
+		} else {
+			logger.Printf("Finished: %s.", summary)
+		}
+
+		err = checkpoint.Save()
+		if err != nil {
+			return err
+		}
+
+		if ctx.Err() != nil && checkpoint != nil {
+			logger.Printf("Saved progress to %s, continue with --resume.", checkpoint.Filename)
+		}
+
+		if sarifFilename != "" {
+			err = writeSARIFFile(sarifFilename, findings)
+			if err != nil {
+				return err
 			}
-		}(errors, logger)
+			logger.Printf("Wrote %d findings to %s", len(findings.List()), sarifFilename)
+		}
+
+		if generationErr != nil {
+			return cli.Exit(fmt.Sprintf("error generating requests: %v", generationErr), 1)
+		}
 
-		fuzzer.ProcessRequests(requests)
-		logger.Printf("Finished.")
+		// Exit with an error so CI treats the run as a failed check.
+		if junit != nil && junit.Failures() > 0 {
+			return cli.Exit(fmt.Sprintf("%d test cases failed", junit.Failures()), 1)
+		}
+
+		// An interrupted run didn't test everything, so it shouldn't pass for a finished one.
+		if ctx.Err() != nil {
+			return cli.Exit("interrupted", 130)
+		}
 	}
 	return nil
 }
 
+// startProgress shows the run's progress on stderr, redrawing a single line on a terminal and printing a line every --progress-interval-s seconds otherwise.
+func startProgress(c *cli.Context, fuzzer *httpfuzz.Fuzzer, requestCount int, logger *log.Logger) *httpfuzz.ProgressReporter {
+	if c.Bool("no-progress") {
+		return nil
+	}
+
+	info, err := os.Stderr.Stat()
+	terminal := err == nil && info.Mode()&os.ModeCharDevice != 0
+	interval := time.Duration(c.Int("progress-interval-s")) * time.Second
+	if terminal {
+		interval = 200 * time.Millisecond
+	}
+
+	progress := httpfuzz.NewProgressReporter(os.Stderr, requestCount, interval, terminal)
+	logger.SetOutput(progress.Wrap(logger.Writer()))
+	progress.Start(fuzzer.Summary)
+	return progress
+}
+
+// handleInterrupts cancels the run on the first Ctrl-C so results received so far are flushed, and exits immediately on the second.
+func handleInterrupts(cancel context.CancelFunc, logger *log.Logger) {
+	interrupts := make(chan os.Signal, 2)
+	signal.Notify(interrupts, os.Interrupt)
+
+	<-interrupts
+	logger.Printf("Interrupted, finishing up. Press Ctrl-C again to exit immediately.")
+	cancel()
+
+	<-interrupts
+	os.Exit(130)
+}
+
+// checkpointerFromFlags starts tracking progress in the --checkpoint file, picking up from it if --resume is set.
+func checkpointerFromFlags(c *cli.Context) (*httpfuzz.Checkpointer, error) {
+	filename := c.String("checkpoint")
+	if filename == "" {
+		if c.Bool("resume") {
+			return nil, fmt.Errorf("--resume needs a --checkpoint file to resume from")
+		}
+		return nil, nil
+	}
+
+	interval := time.Duration(c.Int("checkpoint-interval-s")) * time.Second
+	if c.Bool("resume") {
+		return httpfuzz.ResumeCheckpointer(filename, interval)
+	}
+	return httpfuzz.NewCheckpointer(filename, interval), nil
+}
+
+func writeSARIFFile(filename string, findings *httpfuzz.Findings) error {
+	sarifFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer sarifFile.Close()
+
+	return httpfuzz.WriteSARIF(sarifFile, findings.List())
+}
+
+// matcherFromFlags builds a matcher from the --<prefix>-status, --<prefix>-size, --<prefix>-words, --<prefix>-lines, --<prefix>-time and --<prefix>-regex flags.
+func matcherFromFlags(c *cli.Context, prefix string) (*httpfuzz.Matcher, error) {
+	parseRanges := func(flag string) ([]httpfuzz.Range, error) {
+		return parseRangeFlag(c, fmt.Sprintf("%s-%s", prefix, flag))
+	}
+
+	matcher := &httpfuzz.Matcher{}
+	var err error
+	if matcher.StatusCodes, err = parseRanges("status"); err != nil {
+		return nil, err
+	}
+
+	if matcher.Sizes, err = parseRanges("size"); err != nil {
+		return nil, err
+	}
+
+	if matcher.Words, err = parseRanges("words"); err != nil {
+		return nil, err
+	}
+
+	if matcher.Lines, err = parseRanges("lines"); err != nil {
+		return nil, err
+	}
+
+	if matcher.Times, err = parseRanges("time"); err != nil {
+		return nil, err
+	}
+
+	for _, expression := range c.StringSlice(fmt.Sprintf("%s-regex", prefix)) {
+		compiled, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, err
+		}
+		matcher.Regexps = append(matcher.Regexps, compiled)
+	}
+	return matcher, nil
+}
+
+// parseRangeFlag parses every value of a flag that takes ranges, like --status 200,300-399.
+func parseRangeFlag(c *cli.Context, flag string) ([]httpfuzz.Range, error) {
+	ranges := []httpfuzz.Range{}
+	for _, spec := range c.StringSlice(flag) {
+		parsed, err := httpfuzz.ParseRanges(spec)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, parsed...)
+	}
+	return ranges, nil
+}
+
+// matcherFlags declares the flags read by matcherFromFlags.
+func matcherFlags(prefix, description string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  fmt.Sprintf("%s-status", prefix),
+			Usage: fmt.Sprintf("%s responses with these status codes, like 200,300-399", description),
+		},
+		&cli.StringSliceFlag{
+			Name:  fmt.Sprintf("%s-size", prefix),
+			Usage: fmt.Sprintf("%s responses with body sizes in bytes, like >1024", description),
+		},
+		&cli.StringSliceFlag{
+			Name:  fmt.Sprintf("%s-words", prefix),
+			Usage: fmt.Sprintf("%s responses with this many words in the body", description),
+		},
+		&cli.StringSliceFlag{
+			Name:  fmt.Sprintf("%s-lines", prefix),
+			Usage: fmt.Sprintf("%s responses with this many lines in the body", description),
+		},
+		&cli.StringSliceFlag{
+			Name:  fmt.Sprintf("%s-time", prefix),
+			Usage: fmt.Sprintf("%s responses that took this many milliseconds, like >5000", description),
+		},
+		&cli.StringSliceFlag{
+			Name:  fmt.Sprintf("%s-regex", prefix),
+			Usage: fmt.Sprintf("%s responses with headers or body matching a regular expression", description),
+		},
+	}
+}
+
 func main() {
 	app := &cli.App{
 		Name:   "httpfuzz",
 		Usage:  "fuzz endpoints based on a HTTP request file",
 		Action: actionHTTPFuzz,
+		Commands: []*cli.Command{
+			{
+				Name:   "report",
+				Usage:  "generate an HTML report from a results file written by --output",
+				Action: actionReport,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "input",
+						Required: true,
+						Usage:    "the JSON Lines results file",
+					},
+					&cli.StringFlag{
+						Name:     "output",
+						Required: true,
+						Usage:    "the HTML file to write",
+					},
+				},
+			},
+			{
+				Name:   "query",
+				Usage:  "print results saved by --db without sending anything",
+				Action: actionQuery,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "db",
+						Required: true,
+						Usage:    "the SQLite results database",
+					},
+					&cli.StringSliceFlag{
+						Name:  "status",
+						Usage: "only show results with these status codes, like 200,300-399",
+					},
+					&cli.StringSliceFlag{
+						Name:  "size",
+						Usage: "only show results with body sizes in bytes, like >1024",
+					},
+					&cli.StringSliceFlag{
+						Name:  "time",
+						Usage: "only show results that took this many milliseconds, like >5000",
+					},
+					&cli.StringFlag{
+						Name:  "location",
+						Usage: "only show results with a payload in this location, like header or url param",
+					},
+					&cli.StringFlag{
+						Name:  "field",
+						Usage: "only show results with a payload in this field",
+					},
+					&cli.StringFlag{
+						Name:  "payload-regex",
+						Usage: "only show results with payloads matching a regular expression",
+					},
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "the maximum number of results to show, 0 for no limit",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "how to print results: table or jsonl",
+						Value: "table",
+					},
+				},
+			},
+			{
+				Name:   "replay",
+				Usage:  "resend a single result saved by --output or --db and compare the responses",
+				Action: actionReplay,
+				Flags: append([]cli.Flag{
+					&cli.IntFlag{
+						Name:  "id",
+						Usage: "the ID of the result to resend from --input",
+					},
+					&cli.IntFlag{
+						Name:  "row",
+						Usage: "the row of the result to resend from --db, as printed by httpfuzz query",
+					},
+					&cli.StringFlag{
+						Name:  "input",
+						Usage: "the JSON Lines results file to find the result in",
+					},
+					&cli.StringFlag{
+						Name:  "db",
+						Usage: "the SQLite results database to find the result in",
+					},
+					&cli.StringFlag{
+						Name:  "host",
+						Usage: "send the request to this host and port instead",
+					},
+					&cli.StringSliceFlag{
+						Name:  "header",
+						Usage: "override a header in the request, like \"Authorization: Bearer token\"",
+					},
+					&cli.IntFlag{
+						Name:  "width",
+						Usage: "the width of the side by side comparison in characters",
+						Value: 160,
+					},
+				}, clientFlags()...),
+			},
+		},
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:     "count-only",
 				Required: false,
 				Usage:    "don't send the requests, just count how many would be sent",
 			},
-			&cli.StringFlag{
-				Name:     "seed-request",
-				Required: true,
-				Usage:    "the request to be fuzzed",
-			},
 			&cli.IntFlag{
 				Name:     "delay-ms",
 				Required: false,
 				Usage:    "the delay between each HTTP request in milliseconds",
 			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Usage: "the maximum number of requests in flight at once",
+				Value: httpfuzz.DefaultConcurrency,
+			},
+			&cli.Float64Flag{
+				Name:  "rate",
+				Usage: "the maximum number of requests per second, 0 for no limit",
+			},
+			&cli.IntFlag{
+				Name:  "burst",
+				Usage: "the number of requests that can be sent at once before --rate kicks in",
+				Value: 1,
+			},
+			&cli.BoolFlag{
+				Name:  "adaptive-throttle",
+				Usage: "slow down when the target signals back-pressure and speed back up when it recovers",
+			},
+			&cli.IntSliceFlag{
+				Name:  "throttle-status",
+				Usage: "status codes that make --adaptive-throttle back off",
+				Value: cli.NewIntSlice(http.StatusTooManyRequests, http.StatusServiceUnavailable),
+			},
+			&cli.Float64Flag{
+				Name:  "throttle-error-rate",
+				Usage: "fraction of failed or slow requests that makes --adaptive-throttle back off",
+				Value: 0.5,
+			},
+			&cli.IntFlag{
+				Name:  "throttle-slow-ms",
+				Usage: "responses slower than this count as failures for --adaptive-throttle, 0 to only count errors",
+			},
+			&cli.IntFlag{
+				Name:  "throttle-max-delay-ms",
+				Usage: "the longest --adaptive-throttle will wait between requests",
+				Value: 10000,
+			},
+			&cli.IntFlag{
+				Name:  "throttle-max-pause-s",
+				Usage: "the longest --adaptive-throttle will pause for when the target sends a Retry-After header",
+				Value: 300,
+			},
+			&cli.IntFlag{
+				Name:  "max-attempts",
+				Usage: "the number of times to send a request that fails with a transport error or a --retry-status",
+				Value: 1,
+			},
+			&cli.IntFlag{
+				Name:  "retry-delay-ms",
+				Usage: "the base delay before retrying a request, doubled after each attempt with random jitter",
+				Value: 500,
+			},
+			&cli.IntFlag{
+				Name:  "retry-max-delay-ms",
+				Usage: "the longest delay before retrying a request",
+				Value: 30000,
+			},
+			&cli.IntSliceFlag{
+				Name:  "retry-status",
+				Usage: "status codes that cause a request to be retried",
+			},
 			&cli.StringFlag{
 				Name:     "wordlist",
 				Required: false,
@@ -217,22 +714,6 @@ func main() {
 				Name:     "https",
 				Required: false,
 			},
-			&cli.BoolFlag{
-				Name:     "skip-cert-verify",
-				Required: false,
-				Value:    false,
-				Usage:    "skip verifying SSL certificate when making requests",
-			},
-			&cli.StringFlag{
-				Name:     "proxy-url",
-				Required: false,
-				Usage:    "HTTP proxy to send requests through",
-			},
-			&cli.StringFlag{
-				Name:     "proxy-ca-pem",
-				Required: false,
-				Usage:    "PEM encoded CA Certificate for TLS requests through a proxy",
-			},
 			&cli.StringSliceFlag{
 				Name:  "target-param",
 				Usage: "URL Query string param to fuzz",
@@ -284,8 +765,89 @@ func main() {
 				Name:  "log-output",
 				Usage: "enable to log results to stdout",
 			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "file to write every result to as it arrives",
+			},
+			&cli.StringFlag{
+				Name:  "output-format",
+				Usage: "format of the --output file: jsonl",
+				Value: "jsonl",
+			},
+			&cli.StringFlag{
+				Name:  "html-report",
+				Usage: "file to write a self-contained HTML report of the run to when it finishes",
+			},
+			&cli.StringFlag{
+				Name:  "db",
+				Usage: "SQLite database to save every result to, for slicing with httpfuzz query",
+			},
+			&cli.StringFlag{
+				Name:  "sarif-output",
+				Usage: "file to write findings to as a SARIF 2.1.0 log when the run finishes",
+			},
+			&cli.StringFlag{
+				Name:  "junit-output",
+				Usage: "file to write a JUnit XML report to when the run finishes, exiting with an error if any --fail-* assertion is violated or any request can't be sent",
+			},
+			&cli.StringFlag{
+				Name:  "har-output",
+				Usage: "file to write every request and response to as a HAR 1.2 archive",
+			},
+			&cli.BoolFlag{
+				Name:  "no-progress",
+				Usage: "don't show the run's progress on stderr",
+			},
+			&cli.IntFlag{
+				Name:  "progress-interval-s",
+				Usage: "how often to print progress when stderr isn't a terminal, in seconds",
+				Value: 10,
+			},
+			&cli.StringFlag{
+				Name:  "checkpoint",
+				Usage: "file to periodically save the run's progress to, so it can be continued with --resume",
+			},
+			&cli.IntFlag{
+				Name:  "checkpoint-interval-s",
+				Usage: "how often to save the --checkpoint file, in seconds",
+				Value: 10,
+			},
+			&cli.BoolFlag{
+				Name:  "resume",
+				Usage: "skip the requests completed in the --checkpoint file and continue the run, using the same flags and inputs",
+			},
+			&cli.BoolFlag{
+				Name:  "calibrate",
+				Usage: "measure normal responses for each injection point before fuzzing and only report anomalies",
+			},
+			&cli.IntFlag{
+				Name:  "calibration-payloads",
+				Usage: "the number of random junk payloads sent to each injection point by --calibrate, at most 100",
+				Value: httpfuzz.DefaultCalibrationPayloads,
+			},
+			&cli.Float64Flag{
+				Name:  "anomaly-threshold",
+				Usage: "how far a response must deviate from its --calibrate baseline to be reported, 0 to report everything",
+				Value: 3,
+			},
+			&cli.StringFlag{
+				Name:  "attack-mode",
+				Usage: "how payloads are combined with targets: sniper, pitchfork, clusterbomb or batteringram",
+				Value: string(httpfuzz.SniperAttack),
+			},
+			&cli.StringSliceFlag{
+				Name:  "target-wordlist",
+				Usage: "bind a wordlist to a target as location:field=wordlist, where location is header, param, path-arg or body",
+			},
 		},
 	}
+	app.Flags = append(app.Flags, clientFlags()...)
+	app.Flags = append(app.Flags, seedFlags()...)
+	app.Flags = append(app.Flags, matcherFlags("match", "only show")...)
+	app.Flags = append(app.Flags, matcherFlags("filter", "hide")...)
+	app.Flags = append(app.Flags, matcherFlags("finding", "flag")...)
+	app.Flags = append(app.Flags, matcherFlags("fail", "fail test cases for")...)
+
 	err := app.Run(os.Args)
 	if err != nil {
 		log.Fatal(err)
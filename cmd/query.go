@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/joncooperworks/httpfuzz/sqlitestore"
+	"github.com/urfave/cli/v2"
+)
+
+func actionQuery(c *cli.Context) error {
+	query := &sqlitestore.ResultQuery{
+		Location:  c.String("location"),
+		FieldName: c.String("field"),
+		Limit:     c.Int("limit"),
+	}
+
+	var err error
+	query.StatusCodes, err = parseRangeFlag(c, "status")
+	if err != nil {
+		return err
+	}
+
+	query.Sizes, err = parseRangeFlag(c, "size")
+	if err != nil {
+		return err
+	}
+
+	query.Times, err = parseRangeFlag(c, "time")
+	if err != nil {
+		return err
+	}
+
+	if expression := c.String("payload-regex"); expression != "" {
+		query.Payload, err = regexp.Compile(expression)
+		if err != nil {
+			return err
+		}
+	}
+
+	format := c.String("format")
+	if format != "table" && format != "jsonl" {
+		return fmt.Errorf("unsupported format '%s'", format)
+	}
+
+	// Don't create a database that isn't there.
+	_, err = os.Stat(c.String("db"))
+	if err != nil {
+		return err
+	}
+
+	store, err := sqlitestore.Open(c.String("db"), log.New(os.Stderr, "httpfuzz: ", log.Ldate|log.Ltime|log.Lshortfile))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	records, err := store.Query(query)
+	if err != nil {
+		return err
+	}
+
+	if format == "jsonl" {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, record := range records {
+			err = encoder.Encode(record)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "ROW\tID\tSTATUS\tLENGTH\tWORDS\tLINES\tTIME MS\tLOCATION\tFIELD\tPAYLOAD\tERROR")
+	for _, record := range records {
+		fmt.Fprintf(table, "%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\t%q\t%s\n", record.Row, record.ID, record.StatusCode, record.BodyLength, record.Words, record.Lines, record.TimeElapsedMS, record.Location, record.FieldName, record.Payload, record.Error)
+	}
+	return table.Flush()
+}
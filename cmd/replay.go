@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http/httputil"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/joncooperworks/httpfuzz"
+	"github.com/joncooperworks/httpfuzz/sqlitestore"
+	"github.com/urfave/cli/v2"
+)
+
+func actionReplay(c *cli.Context) error {
+	record, err := loadRecord(c)
+	if err != nil {
+		return err
+	}
+
+	req, err := record.Request()
+	if err != nil {
+		return err
+	}
+
+	if host := c.String("host"); host != "" {
+		req.URL.Host = host
+		req.Host = host
+	}
+
+	for _, header := range c.StringSlice("header") {
+		separator := strings.Index(header, ":")
+		if separator < 1 {
+			return fmt.Errorf("header '%s' must be in the form Name: value", header)
+		}
+		req.Header.Set(strings.TrimSpace(header[:separator]), strings.TrimSpace(header[separator+1:]))
+	}
+
+	httpClient, err := newHTTPClient(c, 1)
+	if err != nil {
+		return err
+	}
+	client := &httpfuzz.Client{Client: httpClient}
+
+	rawRequest, err := httputil.DumpRequestOut(req.Request, true)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	response, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	timeElapsed := time.Since(start)
+	defer response.Body.Close()
+
+	rawResponse, err := httputil.DumpResponse(response.Response, true)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Replaying job %d: payload \"%s\" in %s field \"%s\"\n\n", record.ID, record.Payload, record.Location, record.FieldName)
+	fmt.Printf("%s\n\n", strings.TrimRight(string(rawRequest), "\r\n"))
+	fmt.Printf("Original: [%d] in %dms. Replayed: [%d] in %dms.\n\n", record.StatusCode, record.TimeElapsedMS, response.StatusCode, timeElapsed.Milliseconds())
+	printSideBySide(record.RawResponse, rawResponse, c.Int("width"))
+	return nil
+}
+
+// loadRecord finds the result to replay in the --input results file or the --db store.
+func loadRecord(c *cli.Context) (*httpfuzz.ResultRecord, error) {
+	if dbFilename := c.String("db"); dbFilename != "" {
+		// Job IDs start from 0 in every run saved to the database, so results are found by row instead.
+		if !c.IsSet("row") {
+			return nil, fmt.Errorf("--row is required with --db")
+		}
+
+		// Don't create a database that isn't there.
+		_, err := os.Stat(dbFilename)
+		if err != nil {
+			return nil, err
+		}
+
+		store, err := sqlitestore.Open(dbFilename, log.New(os.Stderr, "httpfuzz: ", log.Ldate|log.Ltime|log.Lshortfile))
+		if err != nil {
+			return nil, err
+		}
+		defer store.Close()
+
+		return store.Record(c.Int("row"))
+	}
+
+	inputFilename := c.String("input")
+	if inputFilename == "" {
+		return nil, fmt.Errorf("one of --input or --db is required")
+	}
+
+	if !c.IsSet("id") {
+		return nil, fmt.Errorf("--id is required with --input")
+	}
+
+	inputFile, err := os.Open(inputFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer inputFile.Close()
+
+	records, err := httpfuzz.ReadResultRecords(inputFile)
+	if err != nil {
+		return nil, err
+	}
+
+	id := c.Int("id")
+	for _, record := range records {
+		if record.ID == id {
+			return record, nil
+		}
+	}
+	return nil, fmt.Errorf("no result with ID %d", id)
+}
+
+// printSideBySide prints two raw responses in columns, marking lines that differ like diff -y.
+// Long lines are wrapped so nothing in the responses is hidden.
+func printSideBySide(original, replayed []byte, width int) {
+	columnWidth := (width - 3) / 2
+	if columnWidth < 10 {
+		columnWidth = 10
+	}
+
+	left := displayRows(original, columnWidth)
+	right := displayRows(replayed, columnWidth)
+	fmt.Printf("%-*s   %s\n", columnWidth, "ORIGINAL RESPONSE", "REPLAYED RESPONSE")
+	fmt.Printf("%s   %s\n", strings.Repeat("-", columnWidth), strings.Repeat("-", columnWidth))
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var leftRow, rightRow string
+		marker := "|"
+		switch {
+		case i >= len(left):
+			rightRow = right[i]
+			marker = ">"
+		case i >= len(right):
+			leftRow = left[i]
+			marker = "<"
+		default:
+			leftRow, rightRow = left[i], right[i]
+			if leftRow == rightRow {
+				marker = " "
+			}
+		}
+		fmt.Printf("%-*s %s %s\n", columnWidth, leftRow, marker, rightRow)
+	}
+}
+
+// displayRows splits raw HTTP into rows no wider than width, replacing anything that would mess up the terminal.
+func displayRows(raw []byte, width int) []string {
+	rows := []string{}
+	for _, line := range strings.Split(strings.TrimRight(string(raw), "\r\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		runes := []rune(strings.Map(func(r rune) rune {
+			if r == '\t' {
+				return ' '
+			}
+
+			if r == utf8.RuneError || !unicode.IsPrint(r) {
+				return '.'
+			}
+			return r
+		}, line))
+
+		for len(runes) > width {
+			rows = append(rows, string(runes[:width]))
+			runes = runes[width:]
+		}
+		rows = append(rows, string(runes))
+	}
+	return rows
+}
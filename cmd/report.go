@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+
+	"github.com/joncooperworks/httpfuzz"
+	"github.com/urfave/cli/v2"
+)
+
+func actionReport(c *cli.Context) error {
+	inputFile, err := os.Open(c.String("input"))
+	if err != nil {
+		return err
+	}
+	defer inputFile.Close()
+
+	records, err := httpfuzz.ReadResultRecords(inputFile)
+	if err != nil {
+		return err
+	}
+
+	reportFile, err := os.Create(c.String("output"))
+	if err != nil {
+		return err
+	}
+	defer reportFile.Close()
+
+	return httpfuzz.WriteHTMLReport(reportFile, records)
+}
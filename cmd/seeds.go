@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/joncooperworks/httpfuzz"
+	"github.com/urfave/cli/v2"
+)
+
+// seedSources are the flags that can provide seed requests.
+var seedSources = []string{"seed-request", "seed-har", "seed-burp", "seed-curl", "seed-openapi", "seed-postman"}
+
+// loadSeeds reads the requests to fuzz from whichever of the seedSources flags is set.
+func loadSeeds(c *cli.Context) ([]*httpfuzz.Seed, error) {
+	// The seed flags can't be required, since urfave/cli would demand them for subcommands too.
+	set := []string{}
+	for _, flag := range seedSources {
+		if c.String(flag) != "" {
+			set = append(set, flag)
+		}
+	}
+
+	if len(set) == 0 {
+		return nil, fmt.Errorf("required flag \"%s\" not set", strings.Join(seedSources, "\" or \""))
+	}
+
+	if len(set) > 1 {
+		return nil, fmt.Errorf("only one of --%s can be set", strings.Join(seedSources, ", --"))
+	}
+
+	switch set[0] {
+	case "seed-har":
+		return seedsFromHARFile(c)
+	case "seed-burp":
+		return seedsFromBurpFile(c)
+	case "seed-openapi":
+		return seedsFromOpenAPIFile(c)
+	case "seed-postman":
+		return seedsFromPostmanFile(c)
+	case "seed-curl":
+		command, err := ioutil.ReadFile(c.String("seed-curl"))
+		if err != nil {
+			return nil, err
+		}
+
+		seedRequest, err := httpfuzz.RequestFromCurl(string(command))
+		if err != nil {
+			return nil, err
+		}
+		return []*httpfuzz.Seed{{Name: c.String("seed-curl"), Request: seedRequest}}, nil
+	default:
+		seedRequest, err := httpfuzz.RequestFromFile(c.String("seed-request"))
+		if err != nil {
+			return nil, err
+		}
+		return []*httpfuzz.Seed{{Name: c.String("seed-request"), Request: seedRequest}}, nil
+	}
+}
+
+func seedsFromHARFile(c *cli.Context) ([]*httpfuzz.Seed, error) {
+	var urlPattern *regexp.Regexp
+	if expression := c.String("har-url"); expression != "" {
+		var err error
+		urlPattern, err = regexp.Compile(expression)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	harFile, err := os.Open(c.String("seed-har"))
+	if err != nil {
+		return nil, err
+	}
+	defer harFile.Close()
+
+	seeds, err := httpfuzz.SeedsFromHAR(harFile, c.IntSlice("har-entry"), urlPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("no entries in %s match --har-entry and --har-url", c.String("seed-har"))
+	}
+	return seeds, nil
+}
+
+func seedsFromBurpFile(c *cli.Context) ([]*httpfuzz.Seed, error) {
+	burpFile, err := os.Open(c.String("seed-burp"))
+	if err != nil {
+		return nil, err
+	}
+	defer burpFile.Close()
+
+	seeds, err := httpfuzz.SeedsFromBurpXML(burpFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("no items in %s", c.String("seed-burp"))
+	}
+	return seeds, nil
+}
+
+func seedsFromOpenAPIFile(c *cli.Context) ([]*httpfuzz.Seed, error) {
+	specFile, err := os.Open(c.String("seed-openapi"))
+	if err != nil {
+		return nil, err
+	}
+	defer specFile.Close()
+
+	delimiter := []byte(c.String("target-delimiter"))[0]
+	seeds, err := httpfuzz.SeedsFromOpenAPI(specFile, c.String("openapi-base-url"), delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("no operations in %s", c.String("seed-openapi"))
+	}
+	return seeds, nil
+}
+
+func seedsFromPostmanFile(c *cli.Context) ([]*httpfuzz.Seed, error) {
+	variables := map[string]string{}
+	if environmentFilename := c.String("postman-environment"); environmentFilename != "" {
+		environmentFile, err := os.Open(environmentFilename)
+		if err != nil {
+			return nil, err
+		}
+		defer environmentFile.Close()
+
+		variables, err = httpfuzz.ReadPostmanEnvironment(environmentFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	collectionFile, err := os.Open(c.String("seed-postman"))
+	if err != nil {
+		return nil, err
+	}
+	defer collectionFile.Close()
+
+	delimiter := []byte(c.String("target-delimiter"))[0]
+	seeds, err := httpfuzz.SeedsFromPostman(collectionFile, variables, c.StringSlice("postman-variable"), delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("no requests in %s", c.String("seed-postman"))
+	}
+	return seeds, nil
+}
+
+// seedFlags declares the flags read by loadSeeds.
+func seedFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "seed-request",
+			Required: false,
+			Usage:    "the request to be fuzzed",
+		},
+		&cli.StringFlag{
+			Name:  "seed-har",
+			Usage: "a HAR file of requests to be fuzzed, like one saved from browser devtools",
+		},
+		&cli.StringFlag{
+			Name:  "seed-burp",
+			Usage: "an XML file of requests to be fuzzed, saved from Burp Suite with \"Save selected items\"",
+		},
+		&cli.StringFlag{
+			Name:  "seed-curl",
+			Usage: "a file containing a curl command line to be fuzzed, like one copied from browser devtools",
+		},
+		&cli.StringFlag{
+			Name:  "seed-openapi",
+			Usage: "an OpenAPI 3 or Swagger 2 document in JSON or YAML, fuzzing every parameter and body property of every operation",
+		},
+		&cli.StringFlag{
+			Name:  "openapi-base-url",
+			Usage: "send --seed-openapi requests here instead of the document's server",
+		},
+		&cli.StringFlag{
+			Name:  "seed-postman",
+			Usage: "a Postman Collection v2.1 file of requests to be fuzzed, including the ones in folders",
+		},
+		&cli.StringFlag{
+			Name:  "postman-environment",
+			Usage: "a Postman environment file with values for the --seed-postman collection's {{variables}}",
+		},
+		&cli.StringSliceFlag{
+			Name:  "postman-variable",
+			Usage: "fuzz wherever this --seed-postman {{variable}} is used",
+		},
+		&cli.IntSliceFlag{
+			Name:  "har-entry",
+			Usage: "only fuzz the --seed-har entry at this index, counting from 0",
+		},
+		&cli.StringFlag{
+			Name:  "har-url",
+			Usage: "only fuzz --seed-har entries with URLs matching this regex",
+		},
+	}
+}
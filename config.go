@@ -2,7 +2,6 @@ package httpfuzz
 
 import (
 	"log"
-	"sync"
 	"time"
 )
 
@@ -21,11 +20,26 @@ type Config struct {
 	FuzzDirectory             bool
 	Wordlist                  *Wordlist
 	Seed                      *Request
+	Seeds                     []*Seed
 	Client                    *Client
 	RequestDelay              time.Duration
+	Concurrency               int
+	RateLimiter               *RateLimiter
+	Throttle                  *Throttle
+	Retry                     *RetryPolicy
+	Match                     *Matcher
+	Filter                    *Matcher
+	CalibrationPayloads       int
+	AnomalyThreshold          float64
+	FindingRules              *Matcher
+	Findings                  *Findings
+	Checkpoint                *Checkpointer
 	Plugins                   *PluginBroker
 	Logger                    *log.Logger
 	URLScheme                 string
 	TargetDelimiter           byte
-	waitGroup                 sync.WaitGroup
+	AttackMode                AttackMode
+	Targets                   []*Target
+	summary                   summary
+	baselines                 map[string]*Baseline
 }
@@ -0,0 +1,430 @@
+package httpfuzz
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+)
+
+// curlFlagsWithArgs are the curl flags httpfuzz understands that take an argument.
+var curlFlagsWithArgs = map[string]string{
+	"-X": "--request",
+	"-H": "--header",
+	"-d": "--data",
+	"-F": "--form",
+	"-b": "--cookie",
+	"-u": "--user",
+	"-A": "--user-agent",
+	"-e": "--referer",
+}
+
+// curlIgnoredFlags only change how curl runs or prints the response, so they don't change the request.
+var curlIgnoredFlags = map[string]bool{
+	"-s": true, "--silent": true, "-S": true, "--show-error": true,
+	"-k": true, "--insecure": true, "-L": true, "--location": true,
+	"-v": true, "--verbose": true, "-i": true, "--include": true,
+	"--http1.1": true, "--http2": true,
+}
+
+// RequestFromCurl parses a curl command line, like one copied from browser devtools, into a request.
+// It understands the flags -X, -H, -d, --data-raw, --data-binary, -F, -b, -u, -A, -e, -G, -I and --compressed, and ignores flags that don't change the request like --silent.
+// --compressed drops any Accept-Encoding header, so Go's transport asks for gzip itself and decompresses responses before they're measured.
+// The request keeps the scheme from the URL, so it doesn't need --https.
+func RequestFromCurl(command string) (*Request, error) {
+	args, err := splitShellWords(command)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, fmt.Errorf("not a curl command")
+	}
+
+	var (
+		method     string
+		rawURL     string
+		data       []string
+		form       []string
+		get        bool
+		compressed bool
+		headers    = http.Header{}
+		host       string
+	)
+	for i := 1; i < len(args); i++ {
+		flag := args[i]
+		if !strings.HasPrefix(flag, "-") || flag == "-" {
+			if rawURL != "" {
+				return nil, fmt.Errorf("curl command has more than one URL")
+			}
+			rawURL = flag
+			continue
+		}
+
+		// Short flags can be grouped, like -sS, and have their argument attached, like -XPOST.
+		var value string
+		hasValue := false
+		if len(flag) > 2 && !strings.HasPrefix(flag, "--") {
+			if _, ok := curlFlagsWithArgs[flag[:2]]; ok {
+				flag, value, hasValue = flag[:2], flag[2:], true
+			} else {
+				args = append(args[:i+1], append([]string{"-" + flag[2:]}, args[i+1:]...)...)
+				flag = flag[:2]
+			}
+		}
+
+		if long, ok := curlFlagsWithArgs[flag]; ok {
+			flag = long
+		}
+
+		switch flag {
+		case "--request", "--header", "--data", "--data-ascii", "--data-raw", "--data-binary", "--form", "--cookie", "--user", "--user-agent", "--referer", "--url":
+			if !hasValue {
+				i++
+				if i == len(args) {
+					return nil, fmt.Errorf("curl flag %s needs an argument", flag)
+				}
+				value = args[i]
+			}
+		}
+
+		switch flag {
+		case "--request":
+			method = value
+		case "--header":
+			name, headerValue, err := parseCurlHeader(value)
+			if err != nil {
+				return nil, err
+			}
+
+			// The Host header is sent from the request's Host field, not its headers.
+			switch http.CanonicalHeaderKey(name) {
+			case "Host":
+				host = headerValue
+			case "Content-Length":
+			default:
+				headers.Add(name, headerValue)
+			}
+		case "--data", "--data-ascii", "--data-binary":
+			if strings.HasPrefix(value, "@") {
+				fileData, err := ioutil.ReadFile(value[1:])
+				if err != nil {
+					return nil, err
+				}
+
+				value = string(fileData)
+				if flag != "--data-binary" {
+					// curl strips newlines from files sent with --data.
+					value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+				}
+			}
+			data = append(data, value)
+		case "--data-raw":
+			data = append(data, value)
+		case "--form":
+			form = append(form, value)
+		case "--cookie":
+			if !strings.Contains(value, "=") {
+				return nil, fmt.Errorf("reading cookies from a file with curl flag %s isn't supported", flag)
+			}
+			headers.Add("Cookie", value)
+		case "--user":
+			credentials := strings.SplitN(value, ":", 2)
+			if len(credentials) == 1 {
+				credentials = append(credentials, "")
+			}
+			basicAuth, _ := http.NewRequest(http.MethodGet, "/", nil)
+			basicAuth.SetBasicAuth(credentials[0], credentials[1])
+			headers.Set("Authorization", basicAuth.Header.Get("Authorization"))
+		case "--user-agent":
+			headers.Set("User-Agent", value)
+		case "--referer":
+			headers.Set("Referer", value)
+		case "--url":
+			rawURL = value
+		case "-G", "--get":
+			get = true
+		case "-I", "--head":
+			method = http.MethodHead
+		case "--compressed":
+			compressed = true
+		default:
+			if !curlIgnoredFlags[flag] {
+				return nil, fmt.Errorf("unsupported curl flag %s", flag)
+			}
+		}
+	}
+
+	// Devtools copies the browser's Accept-Encoding along with --compressed, but Go's transport only decompresses responses when it sets the header itself.
+	if compressed {
+		headers.Del("Accept-Encoding")
+	}
+
+	if rawURL == "" {
+		return nil, fmt.Errorf("curl command has no URL")
+	}
+
+	// curl assumes plain HTTP when the URL has no scheme.
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+
+	var body []byte
+	switch {
+	case len(data) > 0 && len(form) > 0:
+		return nil, fmt.Errorf("curl command can't send both --data and --form")
+	case len(data) > 0 && get:
+		separator := "?"
+		if strings.Contains(rawURL, "?") {
+			separator = "&"
+		}
+		rawURL += separator + strings.Join(data, "&")
+	case len(data) > 0:
+		body = []byte(strings.Join(data, "&"))
+		if headers.Get("Content-Type") == "" {
+			headers.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	case len(form) > 0:
+		var contentType string
+		body, contentType, err = curlMultipartBody(form)
+		if err != nil {
+			return nil, err
+		}
+		headers.Set("Content-Type", contentType)
+	}
+
+	if method == "" {
+		method = http.MethodGet
+		if body != nil {
+			method = http.MethodPost
+		}
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, rawURL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	// The fuzzer replaces the body, so don't let the client fall back to the original one.
+	req.GetBody = nil
+	req.Header = headers
+	if host != "" {
+		req.Host = host
+	}
+	return &Request{req}, nil
+}
+
+func parseCurlHeader(header string) (string, string, error) {
+	// curl sends "Name;" as a header with no value.
+	if strings.HasSuffix(header, ";") && !strings.Contains(header, ":") {
+		return strings.TrimSuffix(header, ";"), "", nil
+	}
+
+	parts := strings.SplitN(header, ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid curl header '%s'", header)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+}
+
+// curlMultipartBody builds a multipart form from curl --form fields.
+// Fields can be name=value, name=@file to upload a file or name=<file to send a file's contents as a value, followed by ;type= and ;filename= options.
+func curlMultipartBody(fields []string) ([]byte, string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for _, field := range fields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, "", fmt.Errorf("invalid curl form field '%s'", field)
+		}
+		name, value := parts[0], parts[1]
+
+		var contentType, filename string
+		isFile := strings.HasPrefix(value, "@") || strings.HasPrefix(value, "<")
+		if isFile {
+			options := strings.Split(value, ";")
+			value = options[0]
+			for _, option := range options[1:] {
+				switch {
+				case strings.HasPrefix(option, "type="):
+					contentType = strings.TrimPrefix(option, "type=")
+				case strings.HasPrefix(option, "filename="):
+					filename = strings.TrimPrefix(option, "filename=")
+				}
+			}
+
+			fileData, err := ioutil.ReadFile(value[1:])
+			if err != nil {
+				return nil, "", err
+			}
+
+			if value[0] == '@' && filename == "" {
+				filename = filepath.Base(value[1:])
+			}
+			value = string(fileData)
+		}
+
+		header := textproto.MIMEHeader{}
+		disposition := fmt.Sprintf("form-data; name=%q", name)
+		if filename != "" {
+			disposition += fmt.Sprintf("; filename=%q", filename)
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+		}
+		header.Set("Content-Disposition", disposition)
+		if contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+
+		_, err = io.WriteString(part, value)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+// splitShellWords splits a command line into arguments the way a POSIX shell would, handling quotes, escapes and line continuations.
+// It also understands the $'...' quotes devtools uses for bodies with special characters.
+func splitShellWords(command string) ([]string, error) {
+	words := []string{}
+	word := &strings.Builder{}
+	inWord := false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\\':
+			i++
+			if i == len(command) {
+				return nil, fmt.Errorf("unfinished escape at the end of the command")
+			}
+
+			// A backslash at the end of a line continues the command on the next one.
+			if command[i] == '\n' {
+				continue
+			}
+			if command[i] == '\r' && i+1 < len(command) && command[i+1] == '\n' {
+				i++
+				continue
+			}
+			word.WriteByte(command[i])
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed ' in command")
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '$' && i+1 < len(command) && command[i+1] == '\'':
+			end, err := readANSICQuote(command, i+2, word)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			inWord = true
+		case c == '"':
+			end, err := readDoubleQuote(command, i+1, word)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// readDoubleQuote copies a double quoted string starting at start into word and returns the index of the closing quote.
+func readDoubleQuote(command string, start int, word *strings.Builder) (int, error) {
+	for i := start; i < len(command); i++ {
+		switch command[i] {
+		case '"':
+			return i, nil
+		case '\\':
+			// Inside double quotes, backslashes only escape characters that are special there.
+			if i+1 < len(command) && strings.IndexByte("\"\\$`\n", command[i+1]) != -1 {
+				i++
+				if command[i] != '\n' {
+					word.WriteByte(command[i])
+				}
+				continue
+			}
+			word.WriteByte('\\')
+		default:
+			word.WriteByte(command[i])
+		}
+	}
+	return 0, fmt.Errorf("unclosed \" in command")
+}
+
+// readANSICQuote copies a $'...' string starting at start into word and returns the index of the closing quote.
+func readANSICQuote(command string, start int, word *strings.Builder) (int, error) {
+	escapes := map[byte]byte{'n': '\n', 'r': '\r', 't': '\t', '\\': '\\', '\'': '\'', '"': '"', '0': 0}
+	for i := start; i < len(command); i++ {
+		switch command[i] {
+		case '\'':
+			return i, nil
+		case '\\':
+			i++
+			if i == len(command) {
+				return 0, fmt.Errorf("unclosed $' in command")
+			}
+
+			if command[i] == 'x' && i+2 < len(command) {
+				decoded, err := hex.DecodeString(command[i+1 : i+3])
+				if err == nil {
+					word.Write(decoded)
+					i += 2
+					continue
+				}
+			}
+
+			if escaped, ok := escapes[command[i]]; ok {
+				word.WriteByte(escaped)
+				continue
+			}
+			word.WriteByte('\\')
+			word.WriteByte(command[i])
+		default:
+			word.WriteByte(command[i])
+		}
+	}
+	return 0, fmt.Errorf("unclosed $' in command")
+}
@@ -0,0 +1,166 @@
+package httpfuzz
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequestFromCurlParsesDevtoolsCommand(t *testing.T) {
+	command := `curl 'https://example.com/api/login?next=%2F' \
+  -H 'authority: example.com' \
+  -H 'content-type: application/json' \
+  -H "X-Token: \"abc\"" \
+  -b 'session=abc; theme=dark' \
+  --data-raw $'{"user":"\x60admin\x60","note":"it\'s"}' \
+  --compressed`
+
+	req, err := RequestFromCurl(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Method != "POST" || req.URL.String() != "https://example.com/api/login?next=%2F" {
+		t.Fatalf("Expected POST to the URL, got %s %s", req.Method, req.URL)
+	}
+
+	if req.Header.Get("Content-Type") != "application/json" || req.Header.Get("X-Token") != `"abc"` || req.Header.Get("Authority") != "example.com" {
+		t.Fatalf("Expected headers to be kept, got %v", req.Header)
+	}
+
+	cookie, err := req.Cookie("theme")
+	if err != nil || cookie.Value != "dark" {
+		t.Fatalf("Expected cookies from -b, got %v", req.Header)
+	}
+
+	count, err := req.BodyTargetCount('`')
+	if err != nil || count != 1 {
+		t.Fatalf("Expected 1 body target, got %d: %v", count, err)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedBody := "{\"user\":\"`admin`\",\"note\":\"it's\"}"
+	if string(body) != expectedBody {
+		t.Fatalf("Expected %s, got %s", expectedBody, body)
+	}
+}
+
+func TestRequestFromCurlLeavesCompressionToTheTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write([]byte("hello"))
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		writer := gzip.NewWriter(w)
+		writer.Write([]byte("hello"))
+		writer.Close()
+	}))
+	defer server.Close()
+
+	req, err := RequestFromCurl("curl '" + server.URL + "' -H 'Accept-Encoding: gzip, deflate, br' --compressed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Header.Get("Accept-Encoding") != "" {
+		t.Fatalf("Expected --compressed to drop Accept-Encoding, got %v", req.Header)
+	}
+
+	response, err := http.DefaultClient.Do(req.Request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "hello" {
+		t.Fatalf("Expected the transport to decompress the response, got %q", body)
+	}
+}
+
+func TestRequestFromCurlDefaults(t *testing.T) {
+	req, err := RequestFromCurl(`curl -sSL -XPUT -u admin:hunter2 -d a=1 -d b=2 -H 'Host: internal' example.com:8080/items`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Method != "PUT" || req.URL.String() != "http://example.com:8080/items" || req.Host != "internal" {
+		t.Fatalf("Expected PUT to plain HTTP with Host internal, got %s %s %s", req.Method, req.URL, req.Host)
+	}
+
+	username, password, ok := req.BasicAuth()
+	if !ok || username != "admin" || password != "hunter2" {
+		t.Fatalf("Expected basic auth from -u, got %v", req.Header)
+	}
+
+	body, _ := ioutil.ReadAll(req.Body)
+	if string(body) != "a=1&b=2" || req.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		t.Fatalf("Expected a form body, got %s", body)
+	}
+
+	req, err = RequestFromCurl(`curl -G -d q=x https://example.com/search`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Method != "GET" || req.URL.RawQuery != "q=x" || req.Body != nil {
+		t.Fatalf("Expected -G to move the data into the query, got %s %s", req.Method, req.URL)
+	}
+}
+
+func TestRequestFromCurlBuildsMultipartForm(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cat.png")
+	err := ioutil.WriteFile(filename, []byte("meow"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := RequestFromCurl(`curl -F title=cat -F "image=@` + filename + `;type=image/png" http://example.com/upload`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Method != "POST" || !req.IsMultipartForm() {
+		t.Fatalf("Expected a multipart POST, got %s %s", req.Method, req.Header.Get("Content-Type"))
+	}
+
+	err = req.ParseMultipartForm(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.MultipartForm.Value["title"][0] != "cat" {
+		t.Fatalf("Expected title field, got %v", req.MultipartForm.Value)
+	}
+
+	image := req.MultipartForm.File["image"][0]
+	if image.Filename != "cat.png" || image.Header.Get("Content-Type") != "image/png" {
+		t.Fatalf("Expected the image file, got %+v", image)
+	}
+}
+
+func TestRequestFromCurlRejectsUnknownFlags(t *testing.T) {
+	_, err := RequestFromCurl(`curl --proxy http://localhost:8080 http://example.com`)
+	if err == nil || !strings.Contains(err.Error(), "--proxy") {
+		t.Fatalf("Expected an error naming the flag, got %v", err)
+	}
+
+	_, err = RequestFromCurl(`curl -H 'unclosed http://example.com`)
+	if err == nil {
+		t.Fatalf("Expected an error for an unclosed quote")
+	}
+}
@@ -27,11 +27,11 @@ func (d *DelimiterArray) Get(position int, delimiter byte) (int, int, error) {
 		return 0, 0, fmt.Errorf("unbalanced delimiters")
 	}
 
-	for i := 0; i < len(delimiterPositions); i++ {
-		if i/2-position <= 1 {
-			return delimiterPositions[i], delimiterPositions[i+1], nil
-		}
+	// Each position is a pair of delimiters, so position n starts at the 2n-th delimiter.
+	start := position * 2
+	if position < 0 || start+1 >= len(delimiterPositions) {
+		return 0, 0, fmt.Errorf("position out of range")
 	}
 
-	return 0, 0, fmt.Errorf("position out of range")
+	return delimiterPositions[start], delimiterPositions[start+1], nil
 }
@@ -37,3 +37,29 @@ func TestDelimiterArrayGetReturnsCorrectOffsets(t *testing.T) {
 		t.Fatalf("Expected end %d got %d", expectedEnd, end)
 	}
 }
+
+func TestDelimiterArrayGetReturnsOffsetsForLaterPositions(t *testing.T) {
+	contents := []byte("`first` and `second`")
+	delimiter := byte('`')
+	array := &DelimiterArray{Contents: contents}
+
+	start, end, err := array.Get(1, delimiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expectedStart = 12
+	const expectedEnd = 19
+	if start != expectedStart {
+		t.Fatalf("Expected start %d, got %d", expectedStart, start)
+	}
+
+	if end != expectedEnd {
+		t.Fatalf("Expected end %d got %d", expectedEnd, end)
+	}
+
+	_, _, err = array.Get(2, delimiter)
+	if err == nil {
+		t.Fatalf("Expected error for out of range position")
+	}
+}
@@ -14,6 +14,11 @@ type bruteForceSuccessful struct {
 
 func (b *bruteForceSuccessful) Listen(results <-chan *httpfuzz.Result) {
 	for result := range results {
+		// Requests that failed without a response have nothing to check.
+		if result.Response == nil {
+			continue
+		}
+
 		// This is a buffer, ReadAll shouldn't fail
 		body, _ := ioutil.ReadAll(result.Response.Body)
 		if !bytes.Contains(body, []byte("Username and/or password incorrect")) {
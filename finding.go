@@ -0,0 +1,97 @@
+package httpfuzz
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Finding is a result that a detector flagged as worth a human's attention.
+// RuleID names the detector: one of the Matcher rule names for findings from Config.FindingRules, or whatever a plugin chose.
+type Finding struct {
+	RuleID  string
+	Message string
+	Record  *ResultRecord
+}
+
+// Findings collects the findings reported during a run.
+// It is safe to use from several goroutines.
+type Findings struct {
+	mutex    sync.Mutex
+	findings []*Finding
+}
+
+// Add records a finding.
+func (f *Findings) Add(finding *Finding) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.findings = append(f.findings, finding)
+}
+
+// List returns every finding reported so far, ordered by job ID and then rule ID.
+func (f *Findings) List() []*Finding {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	findings := append([]*Finding{}, f.findings...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Record.ID != findings[j].Record.ID {
+			return findings[i].Record.ID < findings[j].Record.ID
+		}
+		return findings[i].RuleID < findings[j].RuleID
+	})
+	return findings
+}
+
+// ReportFinding flags a result as a finding for outputs that only report findings, like SARIF.
+// Plugins can call it from Listen to report what their detectors find under their own rule IDs.
+// It does nothing if the fuzzer isn't collecting findings.
+func (r *Result) ReportFinding(ruleID, message string) error {
+	if r.findings == nil {
+		return nil
+	}
+
+	record, err := NewResultRecord(r)
+	if err != nil {
+		return err
+	}
+
+	r.findings.Add(&Finding{RuleID: ruleID, Message: message, Record: record})
+	return nil
+}
+
+// reportFindings flags a result once for each of the fuzzer's finding rules that match it.
+func (f *Fuzzer) reportFindings(result *Result) error {
+	rules, err := f.FindingRules.MatchingRules(result)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		err = result.ReportFinding(rule, findingMessage(rule, result))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findingMessage describes what a finding rule saw in a result.
+func findingMessage(rule string, result *Result) string {
+	switch rule {
+	case StatusRule:
+		return fmt.Sprintf("Response had status code %d", result.Response.StatusCode)
+	case SizeRule:
+		return fmt.Sprintf("Response body was %d bytes", result.BodySize)
+	case WordsRule:
+		return fmt.Sprintf("Response body had %d words", result.Words)
+	case LinesRule:
+		return fmt.Sprintf("Response body had %d lines", result.Lines)
+	case TimeRule:
+		return fmt.Sprintf("Response took %dms", result.TimeElapsed.Milliseconds())
+	case RegexRule:
+		return "Response matched a regular expression"
+	default:
+		return fmt.Sprintf("Response matched the %s rule", rule)
+	}
+}
@@ -0,0 +1,93 @@
+package httpfuzz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+)
+
+type findingReporter struct {
+	t *testing.T
+}
+
+func (f *findingReporter) Listen(results <-chan *Result) {
+	for result := range results {
+		if result.Payload == "admin" {
+			err := result.ReportFinding("admin-accepted", "The admin user was accepted")
+			if err != nil {
+				f.t.Error(err)
+			}
+		}
+	}
+}
+
+func TestFuzzerReportsFindingsFromRulesAndPlugins(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-User") == "root" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("stack trace"))
+		}
+	}))
+	defer server.Close()
+
+	wordlist, err := os.Open("testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	findings := &Findings{}
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	config := &Config{
+		TargetHeaders: []string{"X-User"},
+		Wordlist:      &Wordlist{File: wordlist},
+		Seed:          &Request{request},
+		Client:        &Client{&http.Client{}},
+		Plugins:       testBroker(&findingReporter{t: t}),
+		FindingRules: &Matcher{
+			StatusCodes: []Range{{Min: 500, Max: 599}},
+			Regexps:     []*regexp.Regexp{regexp.MustCompile("stack trace")},
+		},
+		Findings:        findings,
+		TargetDelimiter: '*',
+		Logger:          testLogger(t),
+		URLScheme:       "http",
+	}
+	fuzzer := &Fuzzer{config}
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	fuzzer.ProcessRequests(context.Background(), requests)
+
+	reported := findings.List()
+	if len(reported) != 3 {
+		t.Fatalf("Expected 3 findings, got %d", len(reported))
+	}
+
+	expected := []struct {
+		ruleID  string
+		payload string
+	}{
+		{"admin-accepted", "admin"},
+		{RegexRule, "root"},
+		{StatusRule, "root"},
+	}
+	for i, finding := range reported {
+		if finding.RuleID != expected[i].ruleID || finding.Record.Payload != expected[i].payload {
+			t.Fatalf("Expected finding %s for %s, got %s for %s", expected[i].ruleID, expected[i].payload, finding.RuleID, finding.Record.Payload)
+		}
+	}
+
+	if reported[2].Message != "Response had status code 500" {
+		t.Fatalf("Unexpected message %s", reported[2].Message)
+	}
+}
+
+func TestReportFindingWithoutFindingsDoesNothing(t *testing.T) {
+	result := testResult(t, http.StatusOK, "")
+	err := result.ReportFinding("rule", "message")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,9 +2,16 @@ package httpfuzz
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
 	"time"
 )
 
+// DefaultConcurrency is the number of requests in flight at once when Config.Concurrency isn't set.
+const DefaultConcurrency = 10
+
 const (
 	headerLocation         = "header"
 	bodyLocation           = "body"
@@ -15,11 +22,30 @@ const (
 )
 
 // Job represents a request to send with a payload from the fuzzer.
+// Jobs that place payloads in several targets at once list each of them in Injections, and summarise them in FieldName, Location and Payload.
+// IDs count up from 0 in the order jobs are generated.
 type Job struct {
-	Request   *Request
-	FieldName string
-	Location  string
-	Payload   string
+	ID         int
+	Request    *Request
+	FieldName  string
+	Location   string
+	Payload    string
+	Injections []*Injection
+	position   *Position
+}
+
+// markPosition tells GenerateRequests that the jobs that follow are generated from a new position in the inputs.
+// It sends a job without a request, which GenerateRequests records for checkpoints instead of passing on.
+func markPosition(jobs chan<- *Job, phase string, offsets ...int64) {
+	// Copy the offsets since generators reuse them for the next position.
+	jobs <- &Job{position: &Position{Phase: phase, Offsets: append([]int64{}, offsets...)}}
+}
+
+// Injection is a single payload placed in a single target of a Job's request.
+type Injection struct {
+	Location  string `json:"location"`
+	FieldName string `json:"field_name"`
+	Payload   string `json:"payload"`
 }
 
 // Fuzzer creates HTTP requests from a seed request using the combination of inputs specified in the config.
@@ -28,15 +54,106 @@ type Fuzzer struct {
 	*Config
 }
 
-// GenerateRequests begins generating HTTP requests based on the seed request and sends them into the returned channel.
+// seeds lists the seed requests to fuzz: Seeds, or just Seed if there aren't any.
+func (f *Fuzzer) seeds() []*Seed {
+	if len(f.Seeds) > 0 {
+		return f.Seeds
+	}
+	return []*Seed{{Request: f.Seed}}
+}
+
+// GenerateRequests begins generating HTTP requests based on the seed requests and sends them into the returned channel.
+// Each seed is fuzzed in turn, with the same wordlists and targets, plus any targets of its own.
 // It streams the wordlist from the filesystem line-by-line so it can handle wordlists in constant time.
 // The trade-off is that callers cannot know ahead of time how many requests will be sent.
-func (f *Fuzzer) GenerateRequests() (<-chan *Job, <-chan error) {
+// Generation stops early and the channel is closed when the context is cancelled.
+func (f *Fuzzer) GenerateRequests(ctx context.Context) (<-chan *Job, <-chan error) {
 	jobs := make(chan *Job)
 	errors := make(chan error)
 
+	generated := make(chan *Job)
+	go func(generated chan<- *Job, errors chan<- error) {
+		defer close(generated)
+		resume := f.Checkpoint.resumePosition()
+		for i, seed := range f.seeds() {
+			// A resumed run skips the seeds it already finished and picks up the one it was on from where it left off.
+			start := resume
+			if resume != nil && i < resume.Seed {
+				continue
+			}
+
+			if resume == nil || i > resume.Seed {
+				start = nil
+				generated <- &Job{position: &Position{Seed: i, Phase: seedPhase}}
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			switch f.AttackMode {
+			case PitchforkAttack:
+				f.generatePitchfork(ctx, seed.Request, start, generated, errors)
+			case ClusterBombAttack:
+				f.generateClusterBomb(ctx, seed.Request, start, generated, errors)
+			case BatteringRamAttack:
+				f.generateBatteringRam(ctx, seed, start, generated, errors)
+			default:
+				f.generateSniper(ctx, seed, start, generated, errors)
+			}
+		}
+	}(generated, errors)
+
 	go func(jobs chan<- *Job, errors chan<- error) {
+		// Number jobs in the order they were generated so results can be traced back to them.
+		// A resumed run starts generating from a checkpoint, so it starts counting from there too.
+		id := f.Checkpoint.startJobID()
+		seed := f.Checkpoint.resumePosition().seed()
+		for job := range generated {
+			// Generators stop at the next word once the context is cancelled, so drain what they've already made.
+			if ctx.Err() != nil {
+				continue
+			}
 
+			if job.Request == nil {
+				// Generators don't know which seed they're working on, so positions are tagged with the seed that was last started.
+				if job.position.Phase == seedPhase {
+					seed = job.position.Seed
+				}
+				job.position.Seed = seed
+				f.Checkpoint.markPosition(job.position, id)
+				continue
+			}
+
+			job.ID = id
+			id++
+			if f.Checkpoint.isComplete(job.ID) {
+				continue
+			}
+
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+			}
+		}
+
+		// Signal to consumer that we're done
+		close(jobs)
+		close(errors)
+	}(jobs, errors)
+
+	return jobs, errors
+}
+
+// generateSniper places each word from the wordlist into one target at a time.
+func (f *Fuzzer) generateSniper(ctx context.Context, seed *Seed, start *Position, jobs chan<- *Job, errors chan<- error) {
+	targetHeaders := withSeedTargets(f.TargetHeaders, seed.TargetHeaders)
+	targetParams := withSeedTargets(f.TargetParams, seed.TargetParams)
+	targetPathArgs := withSeedTargets(f.TargetPathArgs, seed.TargetPathArgs)
+
+	// File uploads come before the wordlist, so a run resumed from the wordlist doesn't send them again.
+	if start == nil || start.Phase != wordlistPhase {
+		markPosition(jobs, filesPhase)
 		// Send the file upload stuff independent of the payloads in the wordlist
 		for _, filename := range f.FilesystemPayloads {
 			file, err := FileFrom(filename, "")
@@ -47,7 +164,7 @@ func (f *Fuzzer) GenerateRequests() (<-chan *Job, <-chan error) {
 
 			state := &fuzzerState{
 				PayloadFile: file,
-				Seed:        f.Seed,
+				Seed:        seed.Request,
 			}
 
 			fuzzFiles(state, f.TargetFileKeys, jobs, errors)
@@ -63,46 +180,74 @@ func (f *Fuzzer) GenerateRequests() (<-chan *Job, <-chan error) {
 
 				state := &fuzzerState{
 					PayloadFile: file,
-					Seed:        f.Seed,
+					Seed:        seed.Request,
 				}
 
 				fuzzFiles(state, f.TargetFileKeys, jobs, errors)
 			}
 		}
+	}
 
-		// Generate requests based on the wordlist.
-		for payload := range f.Wordlist.Stream() {
-			state := &fuzzerState{
-				PayloadWord:         payload,
-				Seed:                f.Seed,
-				BodyTargetDelimiter: f.TargetDelimiter,
+	// Generate requests based on the wordlist.
+	stream := f.Wordlist.StreamFrom(start.offset(0))
+	for word := range stream {
+		if ctx.Err() != nil {
+			// Release the wordlist's lock before bailing out.
+			for range stream {
 			}
-			fuzzHeaders(state, f.TargetHeaders, jobs, errors)
-			fuzzURLParams(state, f.TargetParams, jobs, errors)
-			fuzzURLPathArgs(state, f.TargetPathArgs, jobs, errors)
+			return
+		}
 
-			empty := []string{}
-			if f.FuzzDirectory {
-				fuzzDirectoryRoot(state, empty, jobs, errors)
-			}
+		markPosition(jobs, wordlistPhase, word.Offset)
+		payload := word.Text
+		state := &fuzzerState{
+			PayloadWord:         payload,
+			Seed:                seed.Request,
+			BodyTargetDelimiter: f.TargetDelimiter,
+		}
+		fuzzHeaders(state, targetHeaders, jobs, errors)
+		fuzzURLParams(state, targetParams, jobs, errors)
+		fuzzURLPathArgs(state, targetPathArgs, jobs, errors)
+
+		empty := []string{}
+		if f.FuzzDirectory {
+			fuzzDirectoryRoot(state, empty, jobs, errors)
+		}
 
-			// Prevent delimiter code from firing for multipart requests
-			if f.Seed.IsMultipartForm() {
-				fuzzMultipartFormField(state, f.TargetMultipartFieldNames, jobs, errors)
-			} else {
-				fuzzTextBodyWithDelimiters(state, empty, jobs, errors)
+		// Prevent delimiter code from firing for multipart requests
+		if seed.Request.IsMultipartForm() {
+			fuzzMultipartFormField(state, f.TargetMultipartFieldNames, jobs, errors)
+		} else {
+			fuzzTextBodyWithDelimiters(state, empty, jobs, errors)
+		}
+
+		if len(f.TargetFilenames) > 0 {
+			// If there aren't any filesystem payloads or generated payloads, just change the filename
+			if len(f.FilesystemPayloads) == 0 && !f.EnableGeneratedPayloads {
+				fuzzFileNames(state, f.TargetFilenames, jobs, errors)
+				continue
 			}
 
-			if len(f.TargetFilenames) > 0 {
-				// If there aren't any filesystem payloads or generated payloads, just change the filename
-				if len(f.FilesystemPayloads) == 0 && !f.EnableGeneratedPayloads {
-					fuzzFileNames(state, f.TargetFilenames, jobs, errors)
-					continue
+			// Send fuzzed files with filenames from wordlist
+			for _, filename := range f.FilesystemPayloads {
+				file, err := FileFrom(filename, "")
+				if err != nil {
+					errors <- err
+					return
 				}
 
-				// Send fuzzed files with filenames from wordlist
-				for _, filename := range f.FilesystemPayloads {
-					file, err := FileFrom(filename, "")
+				state := &fuzzerState{
+					PayloadFile: file,
+					PayloadWord: payload,
+					Seed:        seed.Request,
+				}
+
+				fuzzFiles(state, f.TargetFilenames, jobs, errors)
+			}
+
+			if f.EnableGeneratedPayloads {
+				for _, fileType := range NativeSupportedFileTypes() {
+					file, err := GenerateFile(fileType, f.FuzzFileSize, "")
 					if err != nil {
 						errors <- err
 						return
@@ -111,54 +256,72 @@ func (f *Fuzzer) GenerateRequests() (<-chan *Job, <-chan error) {
 					state := &fuzzerState{
 						PayloadFile: file,
 						PayloadWord: payload,
-						Seed:        f.Seed,
+						Seed:        seed.Request,
 					}
 
 					fuzzFiles(state, f.TargetFilenames, jobs, errors)
 				}
-
-				if f.EnableGeneratedPayloads {
-					for _, fileType := range NativeSupportedFileTypes() {
-						file, err := GenerateFile(fileType, f.FuzzFileSize, "")
-						if err != nil {
-							errors <- err
-							return
-						}
-
-						state := &fuzzerState{
-							PayloadFile: file,
-							PayloadWord: payload,
-							Seed:        f.Seed,
-						}
-
-						fuzzFiles(state, f.TargetFilenames, jobs, errors)
-					}
-				}
 			}
 		}
-
-		// Signal to consumer that we're done
-		close(jobs)
-		close(errors)
-	}(jobs, errors)
-
-	return jobs, errors
+	}
 }
 
-// RequestCount calculates the total number of requests that will be sent given a set of input and the fields to be fuzzed using combinatorials.
+// RequestCount estimates the total number of requests that will be sent given a set of input and the fields to be fuzzed using combinatorials.
 // This will be slower the larger the input file.
-// It is imperative that this count matches the number of requests created by GenerateRequest, otherwise httpfuzz will wait forever on requests that aren't coming or exit before all requests are processed.
+// When resuming from a checkpoint, requests that were already completed aren't counted.
+// It's only used to show progress and for --count-only, since ProcessRequests finishes when GenerateRequests runs out of jobs no matter how many there were.
 func (f *Fuzzer) RequestCount() (int, error) {
-	count, err := f.Wordlist.Count()
+	count, err := f.totalRequestCount()
 	if err != nil {
 		return 0, err
 	}
 
+	// Jobs completed before a run was resumed won't be sent again.
+	return count - f.Checkpoint.CompletedJobs(), nil
+}
+
+// totalRequestCount is the number of requests in the whole run, including any completed before it was resumed.
+func (f *Fuzzer) totalRequestCount() (int, error) {
+	seeds := f.seeds()
+	switch f.AttackMode {
+	case PitchforkAttack:
+		count, err := f.pitchforkRequestCount()
+		return count * len(seeds), err
+	case ClusterBombAttack:
+		count, err := f.clusterBombRequestCount()
+		return count * len(seeds), err
+	}
+
+	// Only read the wordlist once, no matter how many seeds there are.
+	words, err := f.Wordlist.Count()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, seed := range seeds {
+		var count int
+		if f.AttackMode == BatteringRamAttack {
+			count, err = f.batteringRamRequestCount(seed, words)
+		} else {
+			count, err = f.sniperRequestCount(seed, words)
+		}
+
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// sniperRequestCount is the number of requests the sniper attack sends for a seed, given the number of words in the wordlist.
+func (f *Fuzzer) sniperRequestCount(seed *Seed, count int) (int, error) {
 	multipartFieldTargets := len(f.TargetMultipartFieldNames)
 	// # of requests = # of lines per file * number of targets
-	numRequests := (count * len(f.TargetHeaders)) +
-		(count * len(f.TargetParams)) +
-		(count * len(f.TargetPathArgs)) +
+	numRequests := (count * len(withSeedTargets(f.TargetHeaders, seed.TargetHeaders))) +
+		(count * len(withSeedTargets(f.TargetParams, seed.TargetParams))) +
+		(count * len(withSeedTargets(f.TargetPathArgs, seed.TargetPathArgs))) +
 		(multipartFieldTargets * count) +
 		(len(f.FilesystemPayloads) * len(f.TargetFileKeys))
 
@@ -176,7 +339,7 @@ func (f *Fuzzer) RequestCount() (int, error) {
 			numRequests += (count * len(NativeSupportedFileTypes()) * len(f.TargetFilenames))
 		}
 	} else {
-		bodyTargetCount, err := f.Seed.BodyTargetCount(f.TargetDelimiter)
+		bodyTargetCount, err := seed.Request.BodyTargetCount(f.TargetDelimiter)
 		if err != nil {
 			return 0, err
 		}
@@ -190,16 +353,45 @@ func (f *Fuzzer) RequestCount() (int, error) {
 	return numRequests, nil
 }
 
-// ProcessRequests executes HTTP requests in as they're received over the channel.
-func (f *Fuzzer) ProcessRequests(jobs <-chan *Job) {
+// ProcessRequests executes HTTP requests as they're received over the channel using a fixed pool of workers.
+// A job is only taken off the channel when a worker is free, which holds back GenerateRequests so memory use stays flat no matter how big the wordlist is.
+// Cancelling the context stops taking jobs and cancels requests in flight, then the plugins are sent what was received so far.
+func (f *Fuzzer) ProcessRequests(ctx context.Context, jobs <-chan *Job) {
+	workers := f.Concurrency
+	if workers < 1 {
+		workers = DefaultConcurrency
+	}
+
+	work := make(chan *Job)
+	var running sync.WaitGroup
+	running.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func(work <-chan *Job) {
+			defer running.Done()
+			for job := range work {
+				f.requestWorker(ctx, job)
+			}
+		}(work)
+	}
+
 	for job := range jobs {
-		go f.requestWorker(job)
+		select {
+		case work <- job:
+		case <-ctx.Done():
+		}
 
-		// If there's no delay, it'll return immediately, so we don't need to waste time checking.
-		time.Sleep(f.RequestDelay)
+		// If there's no delay, we don't need to waste time waiting.
+		if f.RequestDelay > 0 {
+			select {
+			case <-time.After(f.RequestDelay):
+			case <-ctx.Done():
+			}
+		}
 	}
+	close(work)
 
-	f.waitGroup.Wait()
+	// The jobs channel is closed once there's nothing left to generate, so the run is over when the workers have finished what they took.
+	running.Wait()
 
 	// Close the plugin chans so they don't wait forever.
 	// It is vital that you close the input chans before waiting, otherwise this will deadlock.
@@ -208,40 +400,110 @@ func (f *Fuzzer) ProcessRequests(jobs <-chan *Job) {
 
 }
 
-func (f *Fuzzer) requestWorker(job *Job) {
-	defer f.waitGroup.Done()
+// completeJob records a job as complete for the checkpoint.
+func (f *Fuzzer) completeJob(jobID int) {
+	err := f.Checkpoint.complete(jobID)
+	if err != nil {
+		f.Logger.Printf("Error saving checkpoint: %v", err)
+	}
+}
 
-	job.Request.URL.Scheme = f.URLScheme
+func (f *Fuzzer) requestWorker(ctx context.Context, job *Job) {
+	// Seeds imported with a full URL already know their scheme.
+	if job.Request.URL.Scheme == "" {
+		job.Request.URL.Scheme = f.URLScheme
+	}
 
 	// Keep the request body around for the plugins.
 	request, err := job.Request.CloneBody(context.Background())
 	if err != nil {
 		f.Logger.Printf("Error cloning request body: %v", err)
+		f.completeJob(job.ID)
 		return
 	}
 
-	// Measure time it took to receive a response from the server.
-	// Useful for blind attacks with delays.
-	start := time.Now()
-	response, err := f.Client.Do(job.Request)
-	if err != nil {
-		f.Logger.Printf("Error sending request: %v", err)
+	response, attempts, timeElapsed, err := f.send(ctx, job.Request)
+	if err != nil && ctx.Err() != nil {
+		// The run was interrupted, not the request, so it'll be sent again on resume instead of being reported.
 		return
 	}
 
-	timeElapsed := time.Since(start)
-
-	if f.LogSuccess {
-		f.Logger.Printf("Payload in %s field \"%s\": %s. Received: [%v]", job.Location, job.FieldName, job.Payload, response.StatusCode)
-	}
+	// Jobs are complete once they've been processed or reported as failed, so a resumed run doesn't send them again.
+	defer f.completeJob(job.ID)
 
 	result := &Result{
+		JobID:       job.ID,
 		Request:     request,
 		Response:    response,
 		Payload:     job.Payload,
 		Location:    job.Location,
 		FieldName:   job.FieldName,
 		TimeElapsed: timeElapsed,
+		Started:     time.Now().Add(-timeElapsed),
+		Injections:  job.Injections,
+		Attempts:    attempts,
+		Error:       err,
+		findings:    f.Findings,
+	}
+
+	// A retryable status on the last attempt means the job ran out of retries, so it's a failure even though there's a response.
+	if err == nil && f.Retry.shouldRetry(response, nil) {
+		result.Error = fmt.Errorf("received retryable status %d on the last attempt", response.StatusCode)
+	}
+
+	if result.Error != nil {
+		f.summary.fail()
+
+		// Report the failure instead of dropping the job so holes in the results can be spotted.
+		f.Logger.Printf("Error sending request after %d attempts, payload in %s field \"%s\": %s: %v", attempts, job.Location, job.FieldName, job.Payload, result.Error)
+	} else {
+		f.summary.receive(response.StatusCode)
+	}
+
+	if response != nil {
+		// Drain and close the body once everyone is done with it so the connection can go back to the pool.
+		defer func() {
+			io.Copy(ioutil.Discard, response.Body)
+			response.Body.Close()
+		}()
+
+		err = measureResponse(result)
+		if err != nil {
+			f.Logger.Printf("Error reading response body: %v", err)
+			return
+		}
+	}
+
+	if result.Error == nil {
+		result.Baseline = f.baselineFor(job.Location, job.FieldName)
+		if result.Baseline != nil {
+			result.Deviation = result.Baseline.Deviation(result)
+		}
+
+		// Results that don't pass the match and filter rules are dropped before they're logged or sent to plugins.
+		interesting, err := f.isInteresting(result)
+		if err != nil {
+			f.Logger.Printf("Error matching response: %v", err)
+			return
+		}
+
+		if !interesting {
+			// Some outputs, like the JUnit report, check every job whether or not it's shown.
+			err = f.Plugins.sendResult(result, false)
+			if err != nil {
+				f.Logger.Printf("Error sending request to plugins: %v", err)
+			}
+			return
+		}
+
+		if f.LogSuccess {
+			f.Logger.Printf("Payload in %s field \"%s\": %s. Received: [%v]", job.Location, job.FieldName, job.Payload, response.StatusCode)
+		}
+
+		err = f.reportFindings(result)
+		if err != nil {
+			f.Logger.Printf("Error reporting findings: %v", err)
+		}
 	}
 
 	err = f.Plugins.SendResult(result)
@@ -251,8 +513,83 @@ func (f *Fuzzer) requestWorker(job *Job) {
 
 }
 
-// WaitFor adds the requests the fuzzer will send to our internal sync.WaitGroup.
-// This keeps the fuzzer running until all requests have been completed.
-func (f *Fuzzer) WaitFor(requests int) {
-	f.waitGroup.Add(requests)
+// isInteresting returns true if a result matches the match rules and doesn't match the filter rules.
+// Every result matches when there are no match rules.
+// When there's an anomaly threshold, calibrated results also have to deviate from their baseline by at least that much.
+func (f *Fuzzer) isInteresting(result *Result) (bool, error) {
+	if f.AnomalyThreshold > 0 && result.Baseline != nil && result.Deviation < f.AnomalyThreshold {
+		return false, nil
+	}
+
+	if !f.Match.IsEmpty() {
+		matched, err := f.Match.Matches(result)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	filtered, err := f.Filter.Matches(result)
+	if err != nil {
+		return false, err
+	}
+	return !filtered, nil
 }
+
+// send sends a request until it succeeds or the retry policy gives up.
+// It returns the last response, the number of attempts made and the time the last attempt took.
+// The response is nil if the last attempt failed without one.
+func (f *Fuzzer) send(ctx context.Context, req *Request) (*Response, int, time.Duration, error) {
+	maxAttempts := f.Retry.maxAttempts()
+	for attempt := 1; ; attempt++ {
+		// Sending a request consumes its body, so every attempt gets its own copy.
+		// Cancelling the context cancels the request if it's in flight.
+		attemptRequest, err := req.CloneBody(ctx)
+		if err != nil {
+			return nil, attempt, 0, err
+		}
+
+		// Wait for the rate limiter right before sending so the limit holds no matter how slow the server is.
+		err = f.RateLimiter.Wait(ctx)
+		if err != nil {
+			return nil, attempt, 0, err
+		}
+
+		err = f.Throttle.Wait(ctx)
+		if err != nil {
+			return nil, attempt, 0, err
+		}
+
+		// Measure time it took to receive a response from the server.
+		// Useful for blind attacks with delays.
+		start := time.Now()
+		response, err := f.Client.Do(attemptRequest)
+		timeElapsed := time.Since(start)
+		f.Throttle.Observe(response, err, timeElapsed)
+		if err != nil {
+			response = nil
+		}
+
+		if attempt >= maxAttempts || !f.Retry.shouldRetry(response, err) || ctx.Err() != nil {
+			return response, attempt, timeElapsed, err
+		}
+
+		if err != nil {
+			f.Logger.Printf("Attempt %d of %d failed, retrying: %v", attempt, maxAttempts, err)
+		} else {
+			f.Logger.Printf("Attempt %d of %d received [%v], retrying", attempt, maxAttempts, response.StatusCode)
+			io.Copy(ioutil.Discard, response.Body)
+			response.Body.Close()
+		}
+
+		select {
+		case <-time.After(f.Retry.backoff(attempt)):
+		case <-ctx.Done():
+			return nil, attempt, timeElapsed, ctx.Err()
+		}
+	}
+}
+
+// WaitFor used to tell ProcessRequests how many requests to wait for.
+//
+// Deprecated: ProcessRequests now returns once every generated job has been processed, so this does nothing.
+func (f *Fuzzer) WaitFor(requests int) {}
@@ -1,11 +1,17 @@
 package httpfuzz
 
 import (
+	"context"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func testLogger(t *testing.T) *log.Logger {
@@ -81,7 +87,7 @@ func TestFuzzerGeneratesExpectedNumberOfRequests(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	requests, _ := fuzzer.GenerateRequests()
+	requests, _ := fuzzer.GenerateRequests(context.Background())
 	count := 0
 	for job := range requests {
 		// A nil request represents the end of stream.
@@ -136,7 +142,7 @@ func TestFuzzerGeneratesCorrectRequestsRequestBody(t *testing.T) {
 		t.Fatalf("Wrong count, expected %d, got %d", sanityCount, expectedCount)
 	}
 
-	requests, _ := fuzzer.GenerateRequests()
+	requests, _ := fuzzer.GenerateRequests(context.Background())
 	count := 0
 	for job := range requests {
 		if job.Request == nil {
@@ -188,7 +194,7 @@ func TestFuzzerGeneratesCorrectRequestsRequestBodyMultipartFile(t *testing.T) {
 		t.Fatalf("Wrong count, expected %d, got %d", sanityCount, expectedCount)
 	}
 
-	requests, _ := fuzzer.GenerateRequests()
+	requests, _ := fuzzer.GenerateRequests(context.Background())
 	count := 0
 	for job := range requests {
 		if job.Request == nil {
@@ -240,7 +246,7 @@ func TestFuzzerGeneratesCorrectRequestsRequestBodyMultipartFileWithoutGeneratedB
 		t.Fatalf("Wrong count, expected %d, got %d", sanityCount, expectedCount)
 	}
 
-	requests, _ := fuzzer.GenerateRequests()
+	requests, _ := fuzzer.GenerateRequests(context.Background())
 	count := 0
 	for job := range requests {
 		if job.Request == nil {
@@ -293,7 +299,7 @@ func TestFuzzerGeneratesCorrectRequestsRequestBodyMultipartFileAndFilenames(t *t
 		t.Fatalf("Wrong count, expected %d, got %d", sanityCount, expectedCount)
 	}
 
-	requests, _ := fuzzer.GenerateRequests()
+	requests, _ := fuzzer.GenerateRequests(context.Background())
 	count := 0
 	for job := range requests {
 		if job.Request == nil {
@@ -340,7 +346,7 @@ func TestFuzzerGeneratesCorrectRequestsRequestBodyMultipartFileFilenamesOnly(t *
 		t.Fatalf("Wrong count, expected %d, got %d", sanityCount, expectedCount)
 	}
 
-	requests, _ := fuzzer.GenerateRequests()
+	requests, _ := fuzzer.GenerateRequests(context.Background())
 
 	count := 0
 	for job := range requests {
@@ -359,3 +365,158 @@ func TestFuzzerGeneratesCorrectRequestsRequestBodyMultipartFileFilenamesOnly(t *
 		t.Fatalf("Too few requests are being sent, expected %d, got %d", expectedCount, count)
 	}
 }
+
+func TestFuzzerLimitsRequestsInFlight(t *testing.T) {
+	const concurrency = 2
+	var mux sync.Mutex
+	inFlight, maxInFlight, received := 0, 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Lock()
+		inFlight++
+		received++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mux.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mux.Lock()
+		inFlight--
+		mux.Unlock()
+	}))
+	defer server.Close()
+
+	wordlist, err := os.Open("testdata/useragents.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	config := &Config{
+		TargetHeaders:   []string{"User-Agent", "Pragma"},
+		Wordlist:        &Wordlist{File: wordlist},
+		Seed:            &Request{request},
+		Client:          &Client{&http.Client{}},
+		Plugins:         &PluginBroker{},
+		Concurrency:     concurrency,
+		TargetDelimiter: '*',
+		Logger:          testLogger(t),
+		URLScheme:       "http",
+	}
+	fuzzer := &Fuzzer{config}
+	expectedCount, err := fuzzer.RequestCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	fuzzer.ProcessRequests(context.Background(), requests)
+
+	if received != expectedCount {
+		t.Fatalf("Expected %d requests, server received %d", expectedCount, received)
+	}
+
+	if maxInFlight > concurrency {
+		t.Fatalf("Expected at most %d requests in flight, got %d", concurrency, maxInFlight)
+	}
+}
+
+func TestProcessRequestsStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var mux sync.Mutex
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Lock()
+		received++
+		first := received == 1
+		mux.Unlock()
+		if first {
+			return
+		}
+
+		// Hang until the client gives up, like a slow target when Ctrl-C is pressed.
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	wordlist, err := os.Open("testdata/useragents.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	collector := &resultCollector{}
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	config := &Config{
+		TargetHeaders:   []string{"User-Agent"},
+		Wordlist:        &Wordlist{File: wordlist},
+		Seed:            &Request{request},
+		Client:          &Client{&http.Client{}},
+		Plugins:         testBroker(collector),
+		Concurrency:     1,
+		TargetDelimiter: '*',
+		Logger:          testLogger(t),
+		URLScheme:       "http",
+	}
+	fuzzer := &Fuzzer{config}
+	requests, _ := fuzzer.GenerateRequests(ctx)
+	fuzzer.ProcessRequests(ctx, requests)
+
+	// ProcessRequests only returns once the plugins have finished, so the collector has everything it'll get.
+	if len(collector.results) != 1 {
+		t.Fatalf("Expected 1 result before cancelling, got %d", len(collector.results))
+	}
+
+	if received != 2 {
+		t.Fatalf("Expected the server to receive 2 requests, got %d", received)
+	}
+
+	summary := fuzzer.Summary()
+	if summary.Received != 1 || summary.Failed != 0 {
+		t.Fatalf("Expected 1 response received and no failures, got %s", summary)
+	}
+}
+
+func TestProcessRequestsFinishesWhenJobsRunOut(t *testing.T) {
+	var mux sync.Mutex
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Lock()
+		received++
+		mux.Unlock()
+	}))
+	defer server.Close()
+
+	// RequestCount counts the trailing newline as another word, which used to leave the run waiting forever for a request that never came.
+	filename := filepath.Join(t.TempDir(), "wordlist.txt")
+	err := ioutil.WriteFile(filename, []byte("admin\nroot\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wordlist, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	config := &Config{
+		TargetHeaders:   []string{"X-User"},
+		Wordlist:        &Wordlist{File: wordlist},
+		Seed:            &Request{request},
+		Client:          &Client{&http.Client{}},
+		Plugins:         &PluginBroker{},
+		TargetDelimiter: '*',
+		Logger:          testLogger(t),
+		URLScheme:       "http",
+	}
+	fuzzer := &Fuzzer{config}
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	fuzzer.ProcessRequests(context.Background(), requests)
+
+	if received != 2 {
+		t.Fatalf("Expected 2 requests, server received %d", received)
+	}
+}
@@ -2,4 +2,8 @@ module github.com/joncooperworks/httpfuzz
 
 go 1.15
 
-require github.com/urfave/cli/v2 v2.2.0
+require (
+	github.com/mattn/go-sqlite3 v1.14.6
+	github.com/urfave/cli/v2 v2.2.0
+	gopkg.in/yaml.v2 v2.4.0
+)
@@ -0,0 +1,357 @@
+package httpfuzz
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR is an HTTP Archive, the format browser devtools use to save network traffic.
+// These types cover the parts of HAR 1.2 httpfuzz reads and writes: http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log *HARLog `json:"log"`
+}
+
+// HARLog is the root of a HAR file.
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator *HARCreator `json:"creator"`
+	Entries []*HAREntry `json:"entries"`
+}
+
+// HARCreator names the application that wrote a HAR file.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a single request and response.
+// Fields starting with an underscore are httpfuzz's custom fields, which the HAR spec allows tools to add.
+type HAREntry struct {
+	StartedDateTime string       `json:"startedDateTime"`
+	Time            float64      `json:"time"`
+	Request         *HARRequest  `json:"request"`
+	Response        *HARResponse `json:"response"`
+	Cache           struct{}     `json:"cache"`
+	Timings         *HARTimings  `json:"timings"`
+	JobID           int          `json:"_jobId"`
+	Payload         string       `json:"_payload,omitempty"`
+	Location        string       `json:"_location,omitempty"`
+	FieldName       string       `json:"_fieldName,omitempty"`
+	Injections      []*Injection `json:"_injections,omitempty"`
+	Attempts        int          `json:"_attempts,omitempty"`
+	Error           string       `json:"_error,omitempty"`
+}
+
+// HARRequest is a request in a HAR entry.
+type HARRequest struct {
+	Method      string          `json:"method"`
+	URL         string          `json:"url"`
+	HTTPVersion string          `json:"httpVersion"`
+	Cookies     []*HARCookie    `json:"cookies"`
+	Headers     []*HARNameValue `json:"headers"`
+	QueryString []*HARNameValue `json:"queryString"`
+	PostData    *HARPostData    `json:"postData,omitempty"`
+	HeadersSize int             `json:"headersSize"`
+	BodySize    int             `json:"bodySize"`
+}
+
+// HARResponse is a response in a HAR entry.
+// Requests that failed have a status of 0.
+type HARResponse struct {
+	Status      int             `json:"status"`
+	StatusText  string          `json:"statusText"`
+	HTTPVersion string          `json:"httpVersion"`
+	Cookies     []*HARCookie    `json:"cookies"`
+	Headers     []*HARNameValue `json:"headers"`
+	Content     *HARContent     `json:"content"`
+	RedirectURL string          `json:"redirectURL"`
+	HeadersSize int             `json:"headersSize"`
+	BodySize    int             `json:"bodySize"`
+}
+
+// HARNameValue is a header or query string parameter.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARCookie is a cookie sent with a request or set by a response.
+type HARCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+// HARPostData is a request body.
+// HAR has no way to mark a binary request body, so httpfuzz base64 encodes bodies that aren't valid UTF-8 and sets the custom _encoding field.
+// Some tools only record form bodies as Params.
+type HARPostData struct {
+	MimeType string      `json:"mimeType"`
+	Text     string      `json:"text"`
+	Params   []*HARParam `json:"params,omitempty"`
+	Encoding string      `json:"_encoding,omitempty"`
+}
+
+// HARParam is a field in a form request body.
+type HARParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// HARContent is a response body.
+// Bodies that aren't valid UTF-8 are base64 encoded.
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARTimings breaks down how long a request took.
+// httpfuzz only measures the whole round trip, so it's all reported as time spent waiting.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HARWriter is a Listener that writes every result it receives to a HAR 1.2 file.
+// Entries are written as they arrive and the file is completed when the run finishes, so a run that's killed leaves the file truncated.
+type HARWriter struct {
+	Writer io.Writer
+	Logger *log.Logger
+}
+
+// ReceivesFailures includes requests that couldn't be sent in the archive, with a response status of 0.
+func (h *HARWriter) ReceivesFailures() bool {
+	return true
+}
+
+// Listen writes results to the Writer until the channel is closed.
+func (h *HARWriter) Listen(results <-chan *Result) {
+	creator, err := json.Marshal(&HARCreator{Name: "httpfuzz", Version: httpfuzzVersion()})
+	if err != nil {
+		h.Logger.Printf("Error writing HAR: %v", err)
+		return
+	}
+
+	_, err = fmt.Fprintf(h.Writer, "{\"log\":{\"version\":\"1.2\",\"creator\":%s,\"entries\":[\n", creator)
+	if err != nil {
+		h.Logger.Printf("Error writing HAR: %v", err)
+		return
+	}
+
+	separator := ""
+	for result := range results {
+		entry, err := NewHAREntry(result)
+		if err != nil {
+			h.Logger.Printf("Error recording result for job %d: %v", result.JobID, err)
+			continue
+		}
+
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			h.Logger.Printf("Error recording result for job %d: %v", result.JobID, err)
+			continue
+		}
+
+		_, err = fmt.Fprintf(h.Writer, "%s%s", separator, encoded)
+		if err != nil {
+			h.Logger.Printf("Error writing result for job %d: %v", result.JobID, err)
+			continue
+		}
+		separator = ",\n"
+	}
+
+	_, err = io.WriteString(h.Writer, "\n]}}\n")
+	if err != nil {
+		h.Logger.Printf("Error writing HAR: %v", err)
+	}
+}
+
+// NewHAREntry copies a Result into a HAR entry.
+// It reads the request and response bodies and puts them back, so the Result can still be used afterwards.
+func NewHAREntry(result *Result) (*HAREntry, error) {
+	request, err := newHARRequest(result.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	milliseconds := float64(result.TimeElapsed) / float64(time.Millisecond)
+	entry := &HAREntry{
+		StartedDateTime: result.Started.Format(time.RFC3339Nano),
+		Time:            milliseconds,
+		Request:         request,
+		Timings:         &HARTimings{Wait: milliseconds},
+		JobID:           result.JobID,
+		Payload:         result.Payload,
+		Location:        result.Location,
+		FieldName:       result.FieldName,
+		Injections:      result.Injections,
+		Attempts:        result.Attempts,
+		Response: &HARResponse{
+			Cookies:     []*HARCookie{},
+			Headers:     []*HARNameValue{},
+			Content:     &HARContent{},
+			HeadersSize: -1,
+		},
+	}
+
+	if result.Error != nil {
+		entry.Error = result.Error.Error()
+	}
+
+	if result.Response == nil {
+		return entry, nil
+	}
+
+	entry.Response, err = newHARResponse(result.Response)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func newHARRequest(req *Request) (*HARRequest, error) {
+	harRequest := &HARRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     harCookies(req.Cookies()),
+		Headers:     harNameValues(req.Header),
+		QueryString: harNameValues(req.URL.Query()),
+		HeadersSize: -1,
+	}
+
+	// Requests loaded from a file carry the Host header in Request.Host.
+	if req.Host != "" && req.Header.Get("Host") == "" {
+		harRequest.Headers = append([]*HARNameValue{{Name: "Host", Value: req.Host}}, harRequest.Headers...)
+	}
+
+	if req.Body == nil {
+		return harRequest, nil
+	}
+
+	clone, err := req.CloneBody(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(clone.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	harRequest.BodySize = len(body)
+	if len(body) == 0 {
+		return harRequest, nil
+	}
+
+	harRequest.PostData = &HARPostData{MimeType: req.Header.Get("Content-Type")}
+	harRequest.PostData.Text, harRequest.PostData.Encoding = harText(body)
+	return harRequest, nil
+}
+
+func newHARResponse(response *Response) (*HARResponse, error) {
+	body, err := response.BodyBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	harResponse := &HARResponse{
+		Status:      response.StatusCode,
+		StatusText:  http.StatusText(response.StatusCode),
+		HTTPVersion: response.Proto,
+		Cookies:     harCookies(response.Cookies()),
+		Headers:     harNameValues(response.Header),
+		RedirectURL: response.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+		Content: &HARContent{
+			Size:     len(body),
+			MimeType: response.Header.Get("Content-Type"),
+		},
+	}
+	harResponse.Content.Text, harResponse.Content.Encoding = harText(body)
+	return harResponse, nil
+}
+
+// harNameValues flattens headers or query params, sorted by name so the output is stable.
+func harNameValues(values map[string][]string) []*HARNameValue {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	nameValues := []*HARNameValue{}
+	for _, name := range names {
+		for _, value := range values[name] {
+			nameValues = append(nameValues, &HARNameValue{Name: name, Value: value})
+		}
+	}
+	return nameValues
+}
+
+func harCookies(cookies []*http.Cookie) []*HARCookie {
+	harCookies := []*HARCookie{}
+	for _, cookie := range cookies {
+		harCookie := &HARCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+
+		if !cookie.Expires.IsZero() {
+			harCookie.Expires = cookie.Expires.Format(time.RFC3339)
+		}
+		harCookies = append(harCookies, harCookie)
+	}
+	return harCookies
+}
+
+// harText returns a body as text, or base64 encoded along with the encoding if it isn't valid UTF-8.
+func harText(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// httpfuzzVersion returns the version of httpfuzz the running binary was built with, if Go recorded it.
+func httpfuzzVersion() string {
+	const modulePath = "github.com/joncooperworks/httpfuzz"
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+
+	for _, dependency := range info.Deps {
+		if dependency.Path == modulePath {
+			return dependency.Version
+		}
+	}
+	return "unknown"
+}
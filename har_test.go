@@ -0,0 +1,109 @@
+package httpfuzz
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHARWriterWritesEntryPerResult(t *testing.T) {
+	req, _ := http.NewRequest("POST", "http://localhost:8000/login?user=admin", strings.NewReader("password=hunter2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Cookie", "session=abc")
+	succeeded := testResult(t, http.StatusOK, "Welcome admin")
+	succeeded.JobID = 7
+	succeeded.Request = &Request{req}
+	succeeded.Location = urlParamLocation
+	succeeded.FieldName = "user"
+	succeeded.Payload = "admin"
+	succeeded.Started = time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	failedReq, _ := http.NewRequest("PUT", "http://localhost:8000/upload", bytes.NewReader([]byte{0xff, 0xfe, 0x00}))
+	failed := &Result{
+		JobID:       8,
+		Request:     &Request{failedReq},
+		Attempts:    3,
+		Error:       errors.New("connection refused"),
+		TimeElapsed: time.Second,
+	}
+
+	output := &bytes.Buffer{}
+	writer := &HARWriter{Writer: output, Logger: testLogger(t)}
+	results := make(chan *Result, 2)
+	results <- succeeded
+	results <- failed
+	close(results)
+	writer.Listen(results)
+
+	har := &HAR{}
+	err := json.Unmarshal(output.Bytes(), har)
+	if err != nil {
+		t.Fatalf("Expected valid HAR, got %v: %s", err, output.String())
+	}
+
+	if har.Log.Version != "1.2" || har.Log.Creator.Name != "httpfuzz" || len(har.Log.Entries) != 2 {
+		t.Fatalf("Unexpected HAR log %+v", har.Log)
+	}
+
+	entry := har.Log.Entries[0]
+	if entry.JobID != 7 || entry.Payload != "admin" || entry.Location != urlParamLocation || entry.FieldName != "user" {
+		t.Fatalf("Unexpected custom fields %+v", entry)
+	}
+
+	if entry.StartedDateTime != "2021-01-02T03:04:05Z" || entry.Time != 250 || entry.Timings.Wait != 250 {
+		t.Fatalf("Unexpected timings %s %f %+v", entry.StartedDateTime, entry.Time, entry.Timings)
+	}
+
+	if entry.Request.PostData.Text != "password=hunter2" || entry.Request.BodySize != 16 {
+		t.Fatalf("Unexpected request body %+v", entry.Request.PostData)
+	}
+
+	if len(entry.Request.QueryString) != 1 || entry.Request.QueryString[0].Value != "admin" {
+		t.Fatalf("Unexpected query string %+v", entry.Request.QueryString)
+	}
+
+	if len(entry.Request.Cookies) != 1 || entry.Request.Cookies[0].Value != "abc" {
+		t.Fatalf("Unexpected cookies %+v", entry.Request.Cookies)
+	}
+
+	if entry.Response.Status != http.StatusOK || entry.Response.Content.Text != "Welcome admin" || entry.Response.Content.MimeType != "text/html" {
+		t.Fatalf("Unexpected response %+v", entry.Response)
+	}
+
+	entry = har.Log.Entries[1]
+	if entry.Error != "connection refused" || entry.Response.Status != 0 {
+		t.Fatalf("Expected failed request in entry, got %+v", entry)
+	}
+
+	if entry.Request.PostData.Encoding != "base64" || entry.Request.PostData.Text != base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe, 0x00}) {
+		t.Fatalf("Expected binary body to be base64 encoded, got %+v", entry.Request.PostData)
+	}
+
+	// The result can still be read after it's been written.
+	body, err := succeeded.Response.BodyBytes()
+	if err != nil || string(body) != "Welcome admin" {
+		t.Fatalf("Expected response body to be intact, got %s", body)
+	}
+}
+
+func TestHARWriterKeepsTheFirstJobID(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost:8000/", nil)
+	result := testResult(t, http.StatusOK, "OK")
+	result.Request = &Request{req}
+
+	output := &bytes.Buffer{}
+	writer := &HARWriter{Writer: output, Logger: testLogger(t)}
+	results := make(chan *Result, 1)
+	results <- result
+	close(results)
+	writer.Listen(results)
+
+	if !strings.Contains(output.String(), `"_jobId":0`) && !strings.Contains(output.String(), `"_jobId": 0`) {
+		t.Fatalf("Expected job 0 to have a _jobId, got %s", output.String())
+	}
+}
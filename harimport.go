@@ -0,0 +1,197 @@
+package httpfuzz
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ReadHAR reads a HAR file, like one saved from browser devtools.
+func ReadHAR(reader io.Reader) (*HAR, error) {
+	har := &HAR{}
+	err := json.NewDecoder(reader).Decode(har)
+	if err != nil {
+		return nil, err
+	}
+
+	if har.Log == nil {
+		return nil, fmt.Errorf("HAR file has no log")
+	}
+	return har, nil
+}
+
+// SeedsFromHAR turns the entries in a HAR file into seeds, so a recorded session can be fuzzed.
+// If indexes are given, only the entries at those indexes are used, and if urlPattern isn't nil, only the entries with a matching URL are.
+// Entries are numbered from 0 in the order they appear in the file.
+func SeedsFromHAR(reader io.Reader, indexes []int, urlPattern *regexp.Regexp) ([]*Seed, error) {
+	har, err := ReadHAR(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := map[int]bool{}
+	for _, index := range indexes {
+		if index < 0 || index >= len(har.Log.Entries) {
+			return nil, fmt.Errorf("HAR file has no entry %d", index)
+		}
+		selected[index] = true
+	}
+
+	seeds := []*Seed{}
+	for i, entry := range har.Log.Entries {
+		if len(selected) > 0 && !selected[i] {
+			continue
+		}
+
+		if entry.Request == nil {
+			return nil, fmt.Errorf("HAR entry %d has no request", i)
+		}
+
+		if urlPattern != nil && !urlPattern.MatchString(entry.Request.URL) {
+			continue
+		}
+
+		req, err := RequestFromHAREntry(entry)
+		if err != nil {
+			return nil, fmt.Errorf("HAR entry %d: %v", i, err)
+		}
+
+		seeds = append(seeds, &Seed{
+			Name:    fmt.Sprintf("HAR entry %d: %s %s", i, entry.Request.Method, entry.Request.URL),
+			Request: req,
+		})
+	}
+	return seeds, nil
+}
+
+// RequestFromHAREntry rebuilds the request recorded in a HAR entry, including its cookies and body.
+// The request keeps the entry's scheme and host, so it doesn't need --https.
+func RequestFromHAREntry(entry *HAREntry) (*Request, error) {
+	if entry.Request == nil {
+		return nil, fmt.Errorf("HAR entry has no request")
+	}
+
+	body, contentType, err := harRequestBody(entry.Request.PostData)
+	if err != nil {
+		return nil, err
+	}
+
+	var bodyReader io.Reader
+	if len(body) > 0 {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(entry.Request.Method, entry.Request.URL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	// The fuzzer replaces the body, so don't let the client fall back to the recorded one.
+	req.GetBody = nil
+
+	for _, header := range entry.Request.Headers {
+		name := http.CanonicalHeaderKey(header.Name)
+		switch {
+		// HTTP/2 pseudo-headers like :authority are recorded alongside the real ones.
+		case strings.HasPrefix(name, ":"):
+		// The host comes from the URL and the content length is worked out from the body, since fuzzing changes it.
+		case name == "Host" || name == "Content-Length":
+		default:
+			req.Header.Add(name, header.Value)
+		}
+	}
+
+	// Cookies are usually recorded in a Cookie header as well, so only add them if they aren't.
+	if req.Header.Get("Cookie") == "" {
+		for _, cookie := range entry.Request.Cookies {
+			req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+
+	if contentType == "" && req.Header.Get("Content-Type") == "" && entry.Request.PostData != nil {
+		contentType = entry.Request.PostData.MimeType
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return &Request{req}, nil
+}
+
+// harRequestBody decodes a HAR request body, building it from the form fields if the text wasn't recorded.
+// It returns the Content-Type the body should be sent with if it's different from the recorded one.
+func harRequestBody(postData *HARPostData) ([]byte, string, error) {
+	if postData == nil {
+		return nil, "", nil
+	}
+
+	if postData.Text != "" || len(postData.Params) == 0 {
+		if postData.Encoding == "base64" {
+			body, err := base64.StdEncoding.DecodeString(postData.Text)
+			return body, "", err
+		}
+		return []byte(postData.Text), "", nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(postData.MimeType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		form := url.Values{}
+		for _, param := range postData.Params {
+			form.Add(param.Name, param.Value)
+		}
+		return []byte(form.Encode()), "", nil
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	contentType := ""
+	if boundary := params["boundary"]; boundary != "" {
+		err = writer.SetBoundary(boundary)
+		if err != nil {
+			return nil, "", err
+		}
+	} else {
+		contentType = writer.FormDataContentType()
+	}
+
+	for _, param := range postData.Params {
+		header := textproto.MIMEHeader{}
+		disposition := fmt.Sprintf("form-data; name=%q", param.Name)
+		if param.FileName != "" {
+			disposition += fmt.Sprintf("; filename=%q", param.FileName)
+		}
+		header.Set("Content-Disposition", disposition)
+		if param.ContentType != "" {
+			header.Set("Content-Type", param.ContentType)
+		}
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+
+		_, err = io.WriteString(part, param.Value)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), contentType, nil
+}
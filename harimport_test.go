@@ -0,0 +1,212 @@
+package httpfuzz
+
+import (
+	"context"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const testHAR = `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "test", "version": "1.0"},
+    "entries": [
+      {
+        "request": {
+          "method": "GET",
+          "url": "https://example.com/users/1?fields=name",
+          "httpVersion": "HTTP/2",
+          "headers": [
+            {"name": ":authority", "value": "example.com"},
+            {"name": "User-Agent", "value": "Firefox"}
+          ],
+          "cookies": [{"name": "session", "value": "abc"}]
+        }
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "http://example.com/login",
+          "httpVersion": "HTTP/1.1",
+          "headers": [
+            {"name": "Content-Type", "value": "application/x-www-form-urlencoded"},
+            {"name": "Content-Length", "value": "3"}
+          ],
+          "postData": {
+            "mimeType": "application/x-www-form-urlencoded",
+            "params": [{"name": "user", "value": "admin"}, {"name": "password", "value": "hunter2"}]
+          }
+        }
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "http://example.com/upload",
+          "httpVersion": "HTTP/1.1",
+          "headers": [],
+          "postData": {
+            "mimeType": "multipart/form-data; boundary=xyz",
+            "params": [
+              {"name": "title", "value": "cat"},
+              {"name": "image", "value": "meow", "fileName": "cat.png", "contentType": "image/png"}
+            ]
+          }
+        }
+      },
+      {
+        "request": {
+          "method": "PUT",
+          "url": "http://example.com/blob",
+          "httpVersion": "HTTP/1.1",
+          "headers": [{"name": "Cookie", "value": "session=def"}],
+          "cookies": [{"name": "session", "value": "def"}],
+          "postData": {"mimeType": "application/octet-stream", "text": "//4A", "_encoding": "base64"}
+        }
+      }
+    ]
+  }
+}`
+
+func TestSeedsFromHARRebuildsEachEntry(t *testing.T) {
+	seeds, err := SeedsFromHAR(strings.NewReader(testHAR), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seeds) != 4 {
+		t.Fatalf("Expected 4 seeds, got %d", len(seeds))
+	}
+
+	if seeds[0].Name != "HAR entry 0: GET https://example.com/users/1?fields=name" {
+		t.Fatalf("Expected the seed to be named after its entry, got %s", seeds[0].Name)
+	}
+
+	get := seeds[0].Request
+	if get.URL.Scheme != "https" || get.URL.Host != "example.com" || get.URL.Query().Get("fields") != "name" {
+		t.Fatalf("Expected the recorded URL, got %s", get.URL)
+	}
+
+	if get.Header.Get("User-Agent") != "Firefox" || get.Header.Get(":authority") != "" {
+		t.Fatalf("Expected pseudo-headers to be dropped, got %v", get.Header)
+	}
+
+	cookie, err := get.Cookie("session")
+	if err != nil || cookie.Value != "abc" {
+		t.Fatalf("Expected the session cookie, got %v", get.Header)
+	}
+
+	login := seeds[1].Request
+	body, err := ioutil.ReadAll(login.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "password=hunter2&user=admin" {
+		t.Fatalf("Expected the form to be built from params, got %s", body)
+	}
+
+	if login.Header.Get("Content-Length") != "" || login.ContentLength != int64(len(body)) {
+		t.Fatalf("Expected the content length to match the body, got %d", login.ContentLength)
+	}
+
+	blob := seeds[3].Request
+	body, err = ioutil.ReadAll(blob.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "\xff\xfe\x00" {
+		t.Fatalf("Expected the base64 body to be decoded, got %q", body)
+	}
+
+	if len(blob.Cookies()) != 1 {
+		t.Fatalf("Expected recorded cookies not to be added twice, got %v", blob.Header)
+	}
+}
+
+func TestSeedsFromHARBuildsMultipartBodies(t *testing.T) {
+	seeds, err := SeedsFromHAR(strings.NewReader(testHAR), []int{2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload := seeds[0].Request
+	if !upload.IsMultipartForm() {
+		t.Fatalf("Expected a multipart form, got %s", upload.Header.Get("Content-Type"))
+	}
+
+	reader := multipart.NewReader(upload.Body, "xyz")
+	form, err := reader.ReadForm(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if form.Value["title"][0] != "cat" {
+		t.Fatalf("Expected title field, got %v", form.Value)
+	}
+
+	image := form.File["image"][0]
+	if image.Filename != "cat.png" || image.Header.Get("Content-Type") != "image/png" {
+		t.Fatalf("Expected the image file, got %+v", image)
+	}
+}
+
+func TestSeedsFromHARSelectsEntries(t *testing.T) {
+	seeds, err := SeedsFromHAR(strings.NewReader(testHAR), []int{0, 1, 3}, regexp.MustCompile("^http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seeds) != 2 || seeds[0].Request.Method != http.MethodPost || seeds[1].Request.Method != http.MethodPut {
+		t.Fatalf("Expected entries 1 and 3, got %v", seeds)
+	}
+
+	_, err = SeedsFromHAR(strings.NewReader(testHAR), []int{4}, nil)
+	if err == nil || err.Error() != "HAR file has no entry 4" {
+		t.Fatalf("Expected an error for a missing entry, got %v", err)
+	}
+}
+
+func TestFuzzerGeneratesRequestsForEverySeed(t *testing.T) {
+	seeds, err := SeedsFromHAR(strings.NewReader(testHAR), []int{0, 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wordlist, err := os.Open("testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	config := &Config{
+		TargetHeaders:   []string{"User-Agent"},
+		Wordlist:        &Wordlist{File: wordlist},
+		Seed:            seeds[0].Request,
+		Seeds:           seeds,
+		Client:          &Client{&http.Client{}},
+		TargetDelimiter: '*',
+		Logger:          testLogger(t),
+		URLScheme:       "http",
+	}
+	fuzzer := &Fuzzer{config}
+	expectedCount, err := fuzzer.RequestCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	hosts := map[string]int{}
+	for job := range requests {
+		hosts[job.Request.URL.Scheme+" "+job.Request.URL.Path]++
+	}
+
+	if expectedCount != 6 || hosts["https /users/1"] != 3 || hosts["http /login"] != 3 {
+		t.Fatalf("Expected 3 requests per seed out of %d, got %v", expectedCount, hosts)
+	}
+}
@@ -44,8 +44,10 @@ func (r *Request) IsMultipartForm() bool {
 func (r *Request) CloneBody(ctx context.Context) (*Request, error) {
 	req := &Request{Request: r.Request.Clone(ctx)}
 
-	// We have to manually set the host in the URL.
-	req.URL.Host = r.Request.Host
+	// We have to manually set the host in the URL, unless the request says where to send it, like one imported from Burp.
+	if req.URL.Host == "" {
+		req.URL.Host = r.Request.Host
+	}
 
 	// Prevent an error when sending the request
 	req.RequestURI = ""
@@ -208,6 +210,53 @@ func (r *Request) SetBodyPayloadAt(position int, delimiter byte, payload string)
 	return nil
 }
 
+// SetBodyPayloads injects payloads at several positions at once and removes the remaining delimiters.
+// The offsets of every position are found before any payload is placed, so payloads containing the delimiter don't shift or unbalance the positions after them.
+func (r *Request) SetBodyPayloads(payloads map[int]string, delimiter byte) error {
+	if r.Body == nil {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	index := &DelimiterArray{Contents: body}
+	offsets := index.Lookup(delimiter)
+	if len(offsets)%2 != 0 {
+		return fmt.Errorf("unbalanced delimiters")
+	}
+
+	for position := range payloads {
+		if position < 0 || position*2 >= len(offsets) {
+			return fmt.Errorf("position out of range")
+		}
+	}
+
+	newBody := []byte{}
+	previous := 0
+	for position := 0; position*2 < len(offsets); position++ {
+		start, end := offsets[position*2], offsets[position*2+1]
+		newBody = append(newBody, body[previous:start]...)
+		if payload, ok := payloads[position]; ok {
+			newBody = append(newBody, []byte(payload)...)
+		} else {
+			newBody = append(newBody, body[start+1:end]...)
+		}
+		previous = end + 1
+	}
+	newBody = append(newBody, body[previous:]...)
+
+	// Adjust content length
+	r.Request.ContentLength = int64(len(newBody))
+
+	// Put back request body with the injected targets.
+	r.Request.Body = ioutil.NopCloser(bytes.NewReader(newBody))
+	return nil
+}
+
 // ReplaceMultipartFileData replaces a file in the request body with a generated payload.
 func (r *Request) ReplaceMultipartFileData(fieldName string, file *File) error {
 	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
@@ -409,3 +458,19 @@ func (r *Response) CloneBody() (*Response, error) {
 	newResponse.Body = ioutil.NopCloser(bytes.NewReader(body))
 	return &Response{Response: newResponse}, nil
 }
+
+// BodyBytes reads the whole response body and puts it back so it can be read again.
+func (r *Response) BodyBytes() ([]byte, error) {
+	if r.Body == nil {
+		return []byte{}, nil
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
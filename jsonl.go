@@ -0,0 +1,55 @@
+package httpfuzz
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+)
+
+// JSONLinesWriter is a Listener that writes every result it receives as a JSON object on its own line.
+// Results are written as they arrive, so the output can be tailed or piped into tools like jq during a run.
+type JSONLinesWriter struct {
+	Writer io.Writer
+	Logger *log.Logger
+}
+
+// ReceivesFailures writes requests that couldn't be sent too, so there are no holes in the results.
+func (j *JSONLinesWriter) ReceivesFailures() bool {
+	return true
+}
+
+// Listen writes results to the Writer until the channel is closed.
+func (j *JSONLinesWriter) Listen(results <-chan *Result) {
+	encoder := json.NewEncoder(j.Writer)
+	for result := range results {
+		record, err := NewResultRecord(result)
+		if err != nil {
+			j.Logger.Printf("Error recording result for job %d: %v", result.JobID, err)
+			continue
+		}
+
+		// Encode writes a single line followed by a newline.
+		err = encoder.Encode(record)
+		if err != nil {
+			j.Logger.Printf("Error writing result for job %d: %v", result.JobID, err)
+		}
+	}
+}
+
+// ReadResultRecords reads the results written by a JSONLinesWriter.
+func ReadResultRecords(reader io.Reader) ([]*ResultRecord, error) {
+	records := []*ResultRecord{}
+	decoder := json.NewDecoder(reader)
+	for {
+		record := &ResultRecord{}
+		err := decoder.Decode(record)
+		if err == io.EOF {
+			return records, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
@@ -0,0 +1,92 @@
+package httpfuzz
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLinesWriterWritesOneRecordPerResult(t *testing.T) {
+	req, _ := http.NewRequest("POST", "http://localhost:8000/login?user=admin", strings.NewReader("password=hunter2"))
+	succeeded := testResult(t, http.StatusOK, "Welcome admin")
+	succeeded.JobID = 7
+	succeeded.Request = &Request{req}
+	succeeded.Location = urlParamLocation
+	succeeded.FieldName = "user"
+	succeeded.Payload = "admin"
+	succeeded.Attempts = 1
+
+	failedReq, _ := http.NewRequest("GET", "http://localhost:8000/", nil)
+	failed := &Result{
+		JobID:       8,
+		Request:     &Request{failedReq},
+		Attempts:    3,
+		Error:       errors.New("connection refused"),
+		TimeElapsed: time.Second,
+	}
+
+	output := &bytes.Buffer{}
+	writer := &JSONLinesWriter{Writer: output, Logger: testLogger(t)}
+	results := make(chan *Result, 2)
+	results <- succeeded
+	results <- failed
+	close(results)
+	writer.Listen(results)
+
+	records := []*ResultRecord{}
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		record := &ResultRecord{}
+		err := json.Unmarshal(scanner.Bytes(), record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(records))
+	}
+
+	record := records[0]
+	if record.ID != 7 || record.StatusCode != http.StatusOK || record.Payload != "admin" || record.BodyLength != 13 || record.BodySHA256 == "" {
+		t.Fatalf("Unexpected record %+v", *record)
+	}
+
+	if !bytes.Contains(record.RawRequest, []byte("password=hunter2")) || !bytes.Contains(record.RawResponse, []byte("Welcome admin")) {
+		t.Fatalf("Raw exchange missing from record %+v", *record)
+	}
+
+	// Recording the result must leave its bodies intact for other listeners.
+	body, _ := ioutil.ReadAll(succeeded.Response.Body)
+	if string(body) != "Welcome admin" {
+		t.Fatalf("Response body was consumed")
+	}
+
+	if records[1].Error != "connection refused" || records[1].StatusCode != 0 || records[1].Attempts != 3 {
+		t.Fatalf("Unexpected failed record %+v", *records[1])
+	}
+}
+
+func TestReadResultRecordsReadsJSONLines(t *testing.T) {
+	input := strings.NewReader("{\"id\": 1, \"payload\": \"admin\"}\n{\"id\": 2, \"payload\": \"root\"}\n")
+	records, err := ReadResultRecords(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 2 || records[0].Payload != "admin" || records[1].ID != 2 {
+		t.Fatalf("Unexpected records %+v", records)
+	}
+
+	_, err = ReadResultRecords(strings.NewReader("{\"id\": 1"))
+	if err == nil {
+		t.Fatalf("Expected error for truncated record")
+	}
+}
@@ -0,0 +1,179 @@
+package httpfuzz
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Errors   int               `xml:"errors,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Cases    []*junitTestCase `xml:"testcase"`
+	elapsed  time.Duration
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure"`
+	Error     *junitProblem `xml:"error"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	jobID     int
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitWriter is a Listener that writes a JUnit XML report when the run finishes, so httpfuzz can be used as a CI gate.
+// Each injection location is a test suite and each job is a test case.
+// A test case fails when its response matches any of the Assertions, like a 500 status code, and errors if its request couldn't be sent.
+// Register it with RegisterUnfiltered so the match and filter rules can't hide failures from it.
+type JUnitWriter struct {
+	Writer     io.Writer
+	Logger     *log.Logger
+	Assertions *Matcher
+	failures   int
+	errors     int
+}
+
+// ReceivesFailures counts requests that couldn't be sent as errors.
+func (j *JUnitWriter) ReceivesFailures() bool {
+	return true
+}
+
+// Listen collects results until the channel is closed, then writes the report.
+func (j *JUnitWriter) Listen(results <-chan *Result) {
+	report := &junitTestSuites{Name: "httpfuzz"}
+	suites := map[string]*junitTestSuite{}
+	var elapsed time.Duration
+	for result := range results {
+		testCase, err := j.testCase(result)
+		if err != nil {
+			j.Logger.Printf("Error recording result for job %d: %v", result.JobID, err)
+			continue
+		}
+
+		suiteName := fmt.Sprintf("%s %s", result.Location, result.FieldName)
+		suite, found := suites[suiteName]
+		if !found {
+			suite = &junitTestSuite{Name: suiteName}
+			suites[suiteName] = suite
+			report.Suites = append(report.Suites, suite)
+		}
+
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		suite.elapsed += result.TimeElapsed
+		elapsed += result.TimeElapsed
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+
+		if testCase.Error != nil {
+			suite.Errors++
+		}
+	}
+
+	// Results arrive in the order requests finish, so list them in the order they were generated.
+	for _, suite := range report.Suites {
+		sort.SliceStable(suite.Cases, func(i, k int) bool {
+			return suite.Cases[i].jobID < suite.Cases[k].jobID
+		})
+		suite.Time = junitSeconds(suite.elapsed)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+	sort.SliceStable(report.Suites, func(i, k int) bool {
+		return report.Suites[i].Cases[0].jobID < report.Suites[k].Cases[0].jobID
+	})
+	report.Time = junitSeconds(elapsed)
+	j.failures = report.Failures
+	j.errors = report.Errors
+
+	_, err := io.WriteString(j.Writer, xml.Header)
+	if err != nil {
+		j.Logger.Printf("Error writing JUnit report: %v", err)
+		return
+	}
+
+	encoder := xml.NewEncoder(j.Writer)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(report)
+	if err != nil {
+		j.Logger.Printf("Error writing JUnit report: %v", err)
+	}
+}
+
+// Failures returns the number of test cases that failed an assertion or errored because their request couldn't be sent.
+// It is only accurate once the run has finished and the report has been written.
+func (j *JUnitWriter) Failures() int {
+	return j.failures + j.errors
+}
+
+func (j *JUnitWriter) testCase(result *Result) (*junitTestCase, error) {
+	violated, err := j.Assertions.MatchingRules(result)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := NewResultRecord(result)
+	if err != nil {
+		return nil, err
+	}
+
+	testCase := &junitTestCase{
+		Name:      fmt.Sprintf("job %d: %s", result.JobID, result.Payload),
+		ClassName: fmt.Sprintf("httpfuzz.%s.%s", result.Location, result.FieldName),
+		Time:      junitSeconds(result.TimeElapsed),
+		SystemOut: string(record.RawRequest),
+		jobID:     result.JobID,
+	}
+
+	if result.Error != nil {
+		testCase.Error = &junitProblem{Message: result.Error.Error(), Type: "error"}
+		return testCase, nil
+	}
+
+	if len(violated) == 0 {
+		return testCase, nil
+	}
+
+	// Most CI systems only show one failure per test case, so every violated assertion goes in the same one.
+	messages := make([]string, len(violated))
+	for i, rule := range violated {
+		messages[i] = findingMessage(rule, result)
+	}
+	testCase.Failure = &junitProblem{
+		Message: strings.Join(messages, "; "),
+		Type:    strings.Join(violated, ","),
+		Text:    string(record.RawResponse),
+	}
+	return testCase, nil
+}
+
+func junitSeconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
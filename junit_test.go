@@ -0,0 +1,142 @@
+package httpfuzz
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJUnitWriterFailsCasesThatViolateAssertions(t *testing.T) {
+	results := make(chan *Result, 4)
+	for i, test := range []struct {
+		location   string
+		fieldName  string
+		payload    string
+		statusCode int
+		err        error
+	}{
+		{urlParamLocation, "id", "'", http.StatusInternalServerError, nil},
+		{headerLocation, "X-User", "admin", http.StatusOK, nil},
+		{headerLocation, "X-User", "root", http.StatusOK, errors.New("connection refused")},
+		{urlParamLocation, "id", "1", http.StatusOK, nil},
+	} {
+		req, _ := http.NewRequest("GET", "http://localhost:8000/", nil)
+		result := testResult(t, test.statusCode, "ok")
+		if test.err != nil {
+			result = &Result{Error: test.err, TimeElapsed: time.Second}
+		}
+		result.JobID = i + 1
+		result.Request = &Request{req}
+		result.Location = test.location
+		result.FieldName = test.fieldName
+		result.Payload = test.payload
+		results <- result
+	}
+	close(results)
+
+	output := &bytes.Buffer{}
+	writer := &JUnitWriter{
+		Writer:     output,
+		Logger:     testLogger(t),
+		Assertions: &Matcher{StatusCodes: []Range{{Min: 500, Max: 599}}},
+	}
+	writer.Listen(results)
+
+	// The request that couldn't be sent counts as a failure too.
+	if writer.Failures() != 2 {
+		t.Fatalf("Expected 2 failures, got %d", writer.Failures())
+	}
+
+	if !strings.HasPrefix(output.String(), xml.Header) {
+		t.Fatalf("Expected XML header")
+	}
+
+	report := &junitTestSuites{}
+	err := xml.Unmarshal(output.Bytes(), report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Tests != 4 || report.Failures != 1 || report.Errors != 1 || len(report.Suites) != 2 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+
+	params := report.Suites[0]
+	if params.Name != "url param id" || params.Tests != 2 || params.Failures != 1 {
+		t.Fatalf("Unexpected suite %+v", params)
+	}
+
+	failed := params.Cases[0]
+	if failed.Name != "job 1: '" || failed.Failure == nil || failed.Failure.Type != StatusRule || failed.Failure.Message != "Response had status code 500" {
+		t.Fatalf("Unexpected test case %+v", failed)
+	}
+
+	if params.Cases[1].Failure != nil {
+		t.Fatalf("Expected job 4 to pass")
+	}
+
+	headers := report.Suites[1]
+	if headers.Errors != 1 || headers.Cases[1].Error == nil || headers.Cases[1].Error.Message != "connection refused" {
+		t.Fatalf("Unexpected suite %+v", headers)
+	}
+}
+
+func TestJUnitWriterSeesResultsHiddenByMatchRules(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-User") == "root" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	wordlist, err := os.Open("testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	output := &bytes.Buffer{}
+	writer := &JUnitWriter{
+		Writer:     output,
+		Logger:     testLogger(t),
+		Assertions: &Matcher{StatusCodes: []Range{{Min: 500, Max: 599}}},
+	}
+	plugins := &PluginBroker{}
+	plugins.RegisterUnfiltered(writer)
+
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	fuzzer := &Fuzzer{&Config{
+		TargetHeaders:   []string{"X-User"},
+		Wordlist:        &Wordlist{File: wordlist},
+		Seed:            &Request{request},
+		Client:          &Client{&http.Client{}},
+		Plugins:         plugins,
+		Match:           &Matcher{StatusCodes: []Range{{Min: 200, Max: 200}}},
+		TargetDelimiter: '*',
+		Logger:          testLogger(t),
+		URLScheme:       "http",
+	}}
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	fuzzer.ProcessRequests(context.Background(), requests)
+
+	if writer.Failures() != 1 {
+		t.Fatalf("Expected the hidden 500 to fail, got %d failures", writer.Failures())
+	}
+
+	report := &junitTestSuites{}
+	err = xml.Unmarshal(output.Bytes(), report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Tests != 3 {
+		t.Fatalf("Expected every job to be a test case, got %d", report.Tests)
+	}
+}
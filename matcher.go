@@ -0,0 +1,192 @@
+package httpfuzz
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Range is an inclusive range of numbers a response attribute can fall in.
+type Range struct {
+	Min int64
+	Max int64
+}
+
+// Contains returns true if a number falls in the range.
+func (r Range) Contains(value int64) bool {
+	return value >= r.Min && value <= r.Max
+}
+
+// ParseRanges parses a comma separated list of numbers and ranges, like "200,300-399,>1000,<10".
+func ParseRanges(spec string) ([]Range, error) {
+	ranges := []Range{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		var parsed Range
+		var err error
+		switch {
+		case strings.HasPrefix(item, ">"):
+			parsed.Min, err = strconv.ParseInt(item[1:], 10, 64)
+			parsed.Min++
+			parsed.Max = math.MaxInt64
+		case strings.HasPrefix(item, "<"):
+			parsed.Max, err = strconv.ParseInt(item[1:], 10, 64)
+			parsed.Max--
+			parsed.Min = math.MinInt64
+		case strings.Contains(item[1:], "-"):
+			separator := strings.Index(item[1:], "-") + 1
+			parsed.Min, err = strconv.ParseInt(item[:separator], 10, 64)
+			if err == nil {
+				parsed.Max, err = strconv.ParseInt(item[separator+1:], 10, 64)
+			}
+		default:
+			parsed.Min, err = strconv.ParseInt(item, 10, 64)
+			parsed.Max = parsed.Min
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid range '%s'", item)
+		}
+		ranges = append(ranges, parsed)
+	}
+	return ranges, nil
+}
+
+// Matcher is a set of rules that pick out results by their response, like ffuf's matchers and filters.
+// A result matches if any rule matches it.
+// Sizes, Words and Lines are measured on the response body, Times in milliseconds and Regexps are run against the response headers and body.
+type Matcher struct {
+	StatusCodes []Range
+	Sizes       []Range
+	Words       []Range
+	Lines       []Range
+	Times       []Range
+	Regexps     []*regexp.Regexp
+}
+
+// IsEmpty returns true if the matcher has no rules.
+func (m *Matcher) IsEmpty() bool {
+	return m == nil || len(m.StatusCodes)+len(m.Sizes)+len(m.Words)+len(m.Lines)+len(m.Times)+len(m.Regexps) == 0
+}
+
+// Matches returns true if any rule matches a result.
+// Results without a response never match.
+func (m *Matcher) Matches(result *Result) (bool, error) {
+	rules, err := m.MatchingRules(result)
+	return len(rules) > 0, err
+}
+
+// Names of the rules in a Matcher, as returned by MatchingRules.
+const (
+	StatusRule = "status"
+	SizeRule   = "size"
+	WordsRule  = "words"
+	LinesRule  = "lines"
+	TimeRule   = "time"
+	RegexRule  = "regex"
+)
+
+// MatchingRules returns the names of the rules that match a result, in the order they're declared in Matcher.
+// Results without a response never match.
+func (m *Matcher) MatchingRules(result *Result) ([]string, error) {
+	rules := []string{}
+	if m.IsEmpty() || result.Response == nil {
+		return rules, nil
+	}
+
+	if inRanges(m.StatusCodes, int64(result.Response.StatusCode)) {
+		rules = append(rules, StatusRule)
+	}
+
+	if inRanges(m.Sizes, result.BodySize) {
+		rules = append(rules, SizeRule)
+	}
+
+	if inRanges(m.Words, int64(result.Words)) {
+		rules = append(rules, WordsRule)
+	}
+
+	if inRanges(m.Lines, int64(result.Lines)) {
+		rules = append(rules, LinesRule)
+	}
+
+	if inRanges(m.Times, result.TimeElapsed.Milliseconds()) {
+		rules = append(rules, TimeRule)
+	}
+
+	if len(m.Regexps) == 0 {
+		return rules, nil
+	}
+
+	raw, err := rawResponse(result.Response)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, expression := range m.Regexps {
+		if expression.Match(raw) {
+			return append(rules, RegexRule), nil
+		}
+	}
+	return rules, nil
+}
+
+func inRanges(ranges []Range, value int64) bool {
+	for _, r := range ranges {
+		if r.Contains(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// rawResponse renders the response headers and body so they can be searched together.
+func rawResponse(response *Response) ([]byte, error) {
+	body, err := response.BodyBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(response.Header))
+	for name := range response.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	raw := &bytes.Buffer{}
+	for _, name := range names {
+		for _, value := range response.Header[name] {
+			fmt.Fprintf(raw, "%s: %s\r\n", name, value)
+		}
+	}
+	raw.WriteString("\r\n")
+	raw.Write(body)
+	return raw.Bytes(), nil
+}
+
+// measureResponse fills in the size, word count and line count of a result's response body.
+func measureResponse(result *Result) error {
+	if result.Response == nil {
+		return nil
+	}
+
+	body, err := result.Response.BodyBytes()
+	if err != nil {
+		return err
+	}
+
+	result.BodySize = int64(len(body))
+	result.Words = len(bytes.Fields(body))
+	if len(body) > 0 {
+		result.Lines = bytes.Count(body, []byte("\n")) + 1
+	}
+	return nil
+}
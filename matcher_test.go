@@ -0,0 +1,124 @@
+package httpfuzz
+
+import (
+	"io/ioutil"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testResult(t *testing.T, statusCode int, body string) *Result {
+	response := &Response{&http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{"Content-Type": []string{"text/html"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}}
+	result := &Result{Response: response, TimeElapsed: 250 * time.Millisecond}
+	err := measureResponse(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestParseRangesParsesNumbersAndRanges(t *testing.T) {
+	ranges, err := ParseRanges("200, 300-399,>1000,<10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Range{
+		{Min: 200, Max: 200},
+		{Min: 300, Max: 399},
+		{Min: 1001, Max: math.MaxInt64},
+		{Min: math.MinInt64, Max: 9},
+	}
+	if !reflect.DeepEqual(expected, ranges) {
+		t.Fatalf("Expected %+v, got %+v", expected, ranges)
+	}
+
+	_, err = ParseRanges("2xx")
+	if err == nil {
+		t.Fatalf("Expected error for invalid range")
+	}
+}
+
+func TestMeasureResponseCountsBody(t *testing.T) {
+	result := testResult(t, http.StatusOK, "one two\nthree\n")
+
+	if result.BodySize != 14 || result.Words != 3 || result.Lines != 3 {
+		t.Fatalf("Expected size 14, 3 words and 3 lines, got size %d, %d words and %d lines", result.BodySize, result.Words, result.Lines)
+	}
+
+	body, _ := ioutil.ReadAll(result.Response.Body)
+	if string(body) != "one two\nthree\n" {
+		t.Fatalf("Measuring the response consumed the body")
+	}
+}
+
+func TestMatcherMatchesAnyRule(t *testing.T) {
+	result := testResult(t, http.StatusOK, "Welcome admin")
+
+	rules := []*Matcher{
+		{StatusCodes: []Range{{Min: 200, Max: 299}}},
+		{Sizes: []Range{{Min: 13, Max: 13}}},
+		{Words: []Range{{Min: 2, Max: 2}}},
+		{Lines: []Range{{Min: 1, Max: 1}}},
+		{Times: []Range{{Min: 200, Max: 300}}},
+		{Regexps: []*regexp.Regexp{regexp.MustCompile("admin")}},
+		{Regexps: []*regexp.Regexp{regexp.MustCompile("Content-Type: text/html")}},
+		{StatusCodes: []Range{{Min: 500, Max: 599}}, Words: []Range{{Min: 2, Max: 2}}},
+	}
+
+	for _, matcher := range rules {
+		matched, err := matcher.Matches(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !matched {
+			t.Fatalf("Expected %+v to match", *matcher)
+		}
+	}
+
+	matcher := &Matcher{StatusCodes: []Range{{Min: 404, Max: 404}}, Regexps: []*regexp.Regexp{regexp.MustCompile("denied")}}
+	matched, err := matcher.Matches(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if matched {
+		t.Fatalf("Expected %+v not to match", *matcher)
+	}
+}
+
+func TestFuzzerAppliesMatchAndFilterRules(t *testing.T) {
+	fuzzer := &Fuzzer{&Config{
+		Match:  &Matcher{StatusCodes: []Range{{Min: 200, Max: 299}}},
+		Filter: &Matcher{Regexps: []*regexp.Regexp{regexp.MustCompile("Not found")}},
+	}}
+
+	cases := []struct {
+		result      *Result
+		interesting bool
+	}{
+		{testResult(t, http.StatusOK, "Welcome"), true},
+		{testResult(t, http.StatusOK, "Not found"), false},
+		{testResult(t, http.StatusForbidden, "Denied"), false},
+	}
+
+	for _, c := range cases {
+		interesting, err := fuzzer.isInteresting(c.result)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if interesting != c.interesting {
+			t.Fatalf("Expected interesting to be %v for status %d", c.interesting, c.result.Response.StatusCode)
+		}
+	}
+}
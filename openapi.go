@@ -0,0 +1,518 @@
+package httpfuzz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// openAPIMethods are the HTTP methods an OpenAPI path item can describe, in the order seeds are generated for them.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// maxSchemaDepth stops example generation from recursing forever through schemas that refer to themselves.
+const maxSchemaDepth = 8
+
+// SeedsFromOpenAPI generates a seed for every operation in an OpenAPI 3 or Swagger 2 document, in JSON or YAML.
+// Parameters and JSON or form body properties are filled in with the examples, defaults or enum values from their schemas, or a placeholder of the right type.
+// Every path parameter, query parameter and header parameter is registered as one of the seed's targets, with path parameters given distinct values so each one is fuzzed on its own, and every body property is surrounded with delimiter so it's fuzzed as a body injection point.
+// Operations that only accept other content types, like multipart forms, are sent without a body.
+// Requests are sent to the document's first server, or baseURL if it isn't empty.
+func SeedsFromOpenAPI(reader io.Reader, baseURL string, delimiter byte) ([]*Seed, error) {
+	raw, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML, so one parser handles both.
+	var decoded interface{}
+	err = yaml.Unmarshal(raw, &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	document, ok := normalizeYAML(decoded).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("OpenAPI document must be an object")
+	}
+
+	spec := &openAPISpec{document: document, delimiter: delimiter}
+	switch {
+	case strings.HasPrefix(stringField(document, "openapi"), "3."):
+	case stringField(document, "swagger") == "2.0":
+		spec.swagger = true
+	default:
+		return nil, fmt.Errorf("only OpenAPI 3 and Swagger 2 documents are supported")
+	}
+
+	if baseURL == "" {
+		baseURL, err = spec.serverURL()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	paths, _ := document["paths"].(map[string]interface{})
+	seeds := []*Seed{}
+	for _, path := range sortedKeys(paths) {
+		pathItem, err := spec.resolve(paths[path])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		for _, method := range openAPIMethods {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			seed, err := spec.seed(baseURL, path, strings.ToUpper(method), pathItem, operation)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %v", strings.ToUpper(method), path, err)
+			}
+			seeds = append(seeds, seed)
+		}
+	}
+	return seeds, nil
+}
+
+type openAPISpec struct {
+	document  map[string]interface{}
+	swagger   bool
+	delimiter byte
+}
+
+// serverURL is the base URL requests are sent to, from the first server in OpenAPI 3 or the host and basePath in Swagger 2.
+func (s *openAPISpec) serverURL() (string, error) {
+	var serverURL string
+	if s.swagger {
+		host := stringField(s.document, "host")
+		if host == "" {
+			return "", fmt.Errorf("Swagger document has no host, set a base URL")
+		}
+
+		scheme := "https"
+		if schemes, ok := s.document["schemes"].([]interface{}); ok && len(schemes) > 0 {
+			scheme = fmt.Sprint(schemes[0])
+		}
+		serverURL = scheme + "://" + host + stringField(s.document, "basePath")
+	} else {
+		servers, _ := s.document["servers"].([]interface{})
+		if len(servers) == 0 {
+			return "", fmt.Errorf("OpenAPI document has no servers, set a base URL")
+		}
+
+		server, _ := servers[0].(map[string]interface{})
+		serverURL = stringField(server, "url")
+		variables, _ := server["variables"].(map[string]interface{})
+		for name, variable := range variables {
+			variable, _ := variable.(map[string]interface{})
+			serverURL = strings.Replace(serverURL, "{"+name+"}", stringField(variable, "default"), -1)
+		}
+	}
+
+	parsed, err := url.Parse(serverURL)
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return "", fmt.Errorf("server URL %s is relative, set a base URL", serverURL)
+	}
+	return serverURL, nil
+}
+
+// seed builds the request for an operation and registers its parameters as targets.
+func (s *openAPISpec) seed(baseURL, path, method string, pathItem, operation map[string]interface{}) (*Seed, error) {
+	parameters, err := s.parameters(pathItem, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := &Seed{Name: method + " " + path}
+	if operationID := stringField(operation, "operationId"); operationID != "" {
+		seed.Name += " (" + operationID + ")"
+	}
+
+	query := url.Values{}
+	headers := http.Header{}
+	cookies := []string{}
+	form := []string{}
+
+	// Fixed segments, like the base URL's, count as taken so path parameters never share a value with them.
+	takenSegments := map[string]bool{}
+	for _, segment := range strings.Split(strings.TrimSuffix(baseURL, "/")+path, "/") {
+		if !strings.Contains(segment, "{") {
+			takenSegments[segment] = true
+		}
+	}
+
+	var jsonBody interface{}
+	for _, parameter := range parameters {
+		name := stringField(parameter, "name")
+		location := stringField(parameter, "in")
+		if location == "body" {
+			jsonBody = parameter["schema"]
+			continue
+		}
+
+		schema := parameter
+		if !s.swagger {
+			schema, err = s.resolve(parameter["schema"])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		value := s.exampleValue(name, parameter, schema)
+		switch location {
+		case "path":
+			segment := uniquePathArg(url.PathEscape(value), takenSegments)
+			path = strings.Replace(path, "{"+name+"}", segment, -1)
+			seed.TargetPathArgs = append(seed.TargetPathArgs, segment)
+		case "query":
+			query.Add(name, value)
+			seed.TargetParams = append(seed.TargetParams, name)
+		case "header":
+			// OpenAPI says these are described elsewhere and ignored as parameters.
+			switch http.CanonicalHeaderKey(name) {
+			case "Accept", "Content-Type", "Authorization":
+				continue
+			}
+			headers.Set(name, value)
+			seed.TargetHeaders = append(seed.TargetHeaders, http.CanonicalHeaderKey(name))
+		case "cookie":
+			cookies = append(cookies, name+"="+value)
+		case "formData":
+			if stringField(schema, "type") == "file" {
+				continue
+			}
+			form = append(form, url.QueryEscape(name)+"="+s.delimited(url.QueryEscape(value)))
+		}
+	}
+
+	var body []byte
+	var contentType string
+	switch {
+	case jsonBody != nil:
+		body, err = s.exampleJSON(jsonBody, "body", 0)
+		contentType = "application/json"
+	case len(form) > 0:
+		body = []byte(strings.Join(form, "&"))
+		contentType = "application/x-www-form-urlencoded"
+	case !s.swagger && operation["requestBody"] != nil:
+		body, contentType, err = s.requestBody(operation["requestBody"])
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	requestURL := strings.TrimSuffix(baseURL, "/") + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// The fuzzer replaces the body, so don't let the client fall back to the generated one.
+	req.GetBody = nil
+	if len(body) == 0 {
+		req.Body = nil
+	}
+
+	req.Header = headers
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if len(cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(cookies, "; "))
+	}
+
+	seed.Request = &Request{req}
+	return seed, nil
+}
+
+// parameters lists an operation's parameters, including the ones shared by its path unless the operation overrides them.
+func (s *openAPISpec) parameters(pathItem, operation map[string]interface{}) ([]map[string]interface{}, error) {
+	parameters := []map[string]interface{}{}
+	overridden := map[string]bool{}
+	for _, list := range []interface{}{operation["parameters"], pathItem["parameters"]} {
+		list, _ := list.([]interface{})
+		for _, parameter := range list {
+			parameter, err := s.resolve(parameter)
+			if err != nil {
+				return nil, err
+			}
+
+			key := stringField(parameter, "in") + " " + stringField(parameter, "name")
+			if overridden[key] {
+				continue
+			}
+			overridden[key] = true
+			parameters = append(parameters, parameter)
+		}
+	}
+	return parameters, nil
+}
+
+// requestBody generates an OpenAPI 3 request body, preferring JSON over forms.
+func (s *openAPISpec) requestBody(requestBody interface{}) ([]byte, string, error) {
+	resolved, err := s.resolve(requestBody)
+	if err != nil {
+		return nil, "", err
+	}
+
+	content, _ := resolved["content"].(map[string]interface{})
+	for _, mediaType := range sortedKeys(content) {
+		if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+			continue
+		}
+
+		media, _ := content[mediaType].(map[string]interface{})
+		body, err := s.exampleJSON(media["schema"], "body", 0)
+		return body, mediaType, err
+	}
+
+	if media, ok := content["application/x-www-form-urlencoded"].(map[string]interface{}); ok {
+		schema, err := s.resolve(media["schema"])
+		if err != nil {
+			return nil, "", err
+		}
+
+		properties, err := s.properties(schema)
+		if err != nil {
+			return nil, "", err
+		}
+
+		form := []string{}
+		for _, name := range sortedKeys(properties) {
+			property, err := s.resolve(properties[name])
+			if err != nil {
+				return nil, "", err
+			}
+			form = append(form, url.QueryEscape(name)+"="+s.delimited(url.QueryEscape(s.exampleValue(name, property, property))))
+		}
+		return []byte(strings.Join(form, "&")), "application/x-www-form-urlencoded", nil
+	}
+	return nil, "", nil
+}
+
+// exampleJSON generates a JSON document for a schema with every scalar value surrounded with the delimiter.
+// Strings are delimited inside their quotes, so payloads are sent as strings.
+func (s *openAPISpec) exampleJSON(schema interface{}, name string, depth int) ([]byte, error) {
+	resolved, err := s.resolve(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if depth > maxSchemaDepth {
+		return []byte("null"), nil
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if choices, ok := resolved[keyword].([]interface{}); ok && len(choices) > 0 {
+			return s.exampleJSON(choices[0], name, depth+1)
+		}
+	}
+
+	properties, err := s.properties(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaType := stringField(resolved, "type")
+	switch {
+	case len(properties) > 0 || schemaType == "object":
+		body := &bytes.Buffer{}
+		body.WriteString("{")
+		for i, property := range sortedKeys(properties) {
+			if i > 0 {
+				body.WriteString(", ")
+			}
+
+			value, err := s.exampleJSON(properties[property], property, depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			key, _ := json.Marshal(property)
+			body.Write(key)
+			body.WriteString(": ")
+			body.Write(value)
+		}
+		body.WriteString("}")
+		return body.Bytes(), nil
+	case schemaType == "array":
+		item, err := s.exampleJSON(resolved["items"], name, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return []byte("[" + string(item) + "]"), nil
+	case schemaType == "integer" || schemaType == "number" || schemaType == "boolean":
+		return []byte(s.delimited(s.exampleValue(name, resolved, resolved))), nil
+	default:
+		quoted, _ := json.Marshal(s.exampleValue(name, resolved, resolved))
+		return []byte(`"` + s.delimited(string(quoted[1:len(quoted)-1])) + `"`), nil
+	}
+}
+
+// properties merges an object schema's properties with the ones from its allOf schemas.
+func (s *openAPISpec) properties(schema map[string]interface{}) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	if own, ok := schema["properties"].(map[string]interface{}); ok {
+		for name, property := range own {
+			properties[name] = property
+		}
+	}
+
+	allOf, _ := schema["allOf"].([]interface{})
+	for _, part := range allOf {
+		resolved, err := s.resolve(part)
+		if err != nil {
+			return nil, err
+		}
+
+		inherited, err := s.properties(resolved)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, property := range inherited {
+			properties[name] = property
+		}
+	}
+	return properties, nil
+}
+
+// exampleValue picks a value for a parameter or property from its example, default or enum, or makes one up from its type.
+// OpenAPI 3 parameters have their examples outside their schema, so both are checked.
+func (s *openAPISpec) exampleValue(name string, parameter, schema map[string]interface{}) string {
+	for _, source := range []map[string]interface{}{parameter, schema} {
+		for _, keyword := range []string{"example", "x-example", "default"} {
+			if value, ok := source[keyword]; ok && isScalar(value) {
+				return s.withoutDelimiter(fmt.Sprint(value))
+			}
+		}
+
+		if enum, ok := source["enum"].([]interface{}); ok && len(enum) > 0 && isScalar(enum[0]) {
+			return s.withoutDelimiter(fmt.Sprint(enum[0]))
+		}
+	}
+
+	switch stringField(schema, "type") {
+	case "integer", "number":
+		return "1"
+	case "boolean":
+		return "true"
+	}
+
+	switch stringField(schema, "format") {
+	case "date":
+		return "2021-01-01"
+	case "date-time":
+		return "2021-01-01T00:00:00Z"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "uri", "url":
+		return "https://example.com"
+	}
+	return s.withoutDelimiter(name)
+}
+
+func (s *openAPISpec) delimited(value string) string {
+	return string(s.delimiter) + value + string(s.delimiter)
+}
+
+// withoutDelimiter removes the delimiter from example values so it doesn't create extra injection points.
+func (s *openAPISpec) withoutDelimiter(value string) string {
+	return strings.Replace(value, string(s.delimiter), "", -1)
+}
+
+// resolve follows local $refs, like #/components/schemas/User, to the object they point at.
+func (s *openAPISpec) resolve(value interface{}) (map[string]interface{}, error) {
+	for i := 0; i < maxSchemaDepth; i++ {
+		object, _ := value.(map[string]interface{})
+		ref, ok := object["$ref"].(string)
+		if !ok {
+			if object == nil {
+				object = map[string]interface{}{}
+			}
+			return object, nil
+		}
+
+		if !strings.HasPrefix(ref, "#/") {
+			return nil, fmt.Errorf("only local $refs are supported, got %s", ref)
+		}
+
+		value = s.document
+		for _, token := range strings.Split(ref[2:], "/") {
+			token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+			parent, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("$ref %s not found", ref)
+			}
+
+			value, ok = parent[token]
+			if !ok {
+				return nil, fmt.Errorf("$ref %s not found", ref)
+			}
+		}
+	}
+	return nil, fmt.Errorf("too many nested $refs")
+}
+
+// normalizeYAML converts the map[interface{}]interface{} YAML decodes objects into to map[string]interface{} like JSON does.
+func normalizeYAML(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		object := map[string]interface{}{}
+		for key, child := range value {
+			object[fmt.Sprint(key)] = normalizeYAML(child)
+		}
+		return object
+	case []interface{}:
+		for i, child := range value {
+			value[i] = normalizeYAML(child)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return false
+	default:
+		return true
+	}
+}
+
+func stringField(object map[string]interface{}, key string) string {
+	value, _ := object[key].(string)
+	return value
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,293 @@
+package httpfuzz
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const testOpenAPI3 = `
+openapi: 3.0.1
+info:
+  title: Users
+  version: "1.0"
+servers:
+  - url: https://{environment}.example.com/v1
+    variables:
+      environment:
+        default: api
+paths:
+  /users/{id}:
+    parameters:
+      - $ref: '#/components/parameters/UserID'
+    get:
+      operationId: getUser
+      parameters:
+        - name: fields
+          in: query
+          schema:
+            type: string
+            enum: [name, email]
+        - name: X-Request-ID
+          in: header
+          schema:
+            type: string
+            format: uuid
+        - name: Accept
+          in: header
+          schema:
+            type: string
+    put:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/User'
+components:
+  parameters:
+    UserID:
+      name: id
+      in: path
+      required: true
+      example: 42
+      schema:
+        type: integer
+  schemas:
+    User:
+      allOf:
+        - $ref: '#/components/schemas/Named'
+        - type: object
+          properties:
+            age:
+              type: integer
+            tags:
+              type: array
+              items:
+                type: string
+                example: admin
+    Named:
+      type: object
+      properties:
+        name:
+          type: string
+          example: Jon "JC" Cooper
+`
+
+const testSwagger2 = `{
+  "swagger": "2.0",
+  "info": {"title": "Login", "version": "1.0"},
+  "host": "example.com",
+  "basePath": "/api",
+  "schemes": ["http"],
+  "paths": {
+    "/login": {
+      "post": {
+        "consumes": ["application/x-www-form-urlencoded"],
+        "parameters": [
+          {"name": "user", "in": "formData", "type": "string", "default": "admin"},
+          {"name": "remember", "in": "formData", "type": "boolean"}
+        ]
+      }
+    },
+    "/users": {
+      "post": {
+        "parameters": [
+          {"name": "body", "in": "body", "schema": {"$ref": "#/definitions/User"}}
+        ]
+      }
+    }
+  },
+  "definitions": {
+    "User": {"type": "object", "properties": {"email": {"type": "string", "format": "email"}}}
+  }
+}`
+
+func TestSeedsFromOpenAPI3(t *testing.T) {
+	seeds, err := SeedsFromOpenAPI(strings.NewReader(testOpenAPI3), "", '`')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seeds) != 2 {
+		t.Fatalf("Expected a seed per operation, got %d", len(seeds))
+	}
+
+	get := seeds[0]
+	if get.Name != "GET /users/{id} (getUser)" {
+		t.Fatalf("Expected the seed to be named after the operation, got %s", get.Name)
+	}
+
+	if get.Request.URL.String() != "https://api.example.com/v1/users/42?fields=name" {
+		t.Fatalf("Expected examples to be filled in, got %s", get.Request.URL)
+	}
+
+	if get.Request.Header.Get("X-Request-Id") != "00000000-0000-0000-0000-000000000000" || get.Request.Header.Get("Accept") != "" {
+		t.Fatalf("Expected the X-Request-ID header only, got %v", get.Request.Header)
+	}
+
+	if !reflect.DeepEqual(get.TargetPathArgs, []string{"42"}) || !reflect.DeepEqual(get.TargetParams, []string{"fields"}) || !reflect.DeepEqual(get.TargetHeaders, []string{"X-Request-Id"}) {
+		t.Fatalf("Expected every parameter to be a target, got %+v", get)
+	}
+
+	put := seeds[1]
+	body, err := ioutil.ReadAll(put.Request.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedBody := "{\"age\": `1`, \"name\": \"`Jon \\\"JC\\\" Cooper`\", \"tags\": [\"`admin`\"]}"
+	if string(body) != expectedBody {
+		t.Fatalf("Expected %s, got %s", expectedBody, body)
+	}
+
+	if put.Request.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected a JSON body, got %s", put.Request.Header.Get("Content-Type"))
+	}
+}
+
+func TestSeedsFromSwagger2(t *testing.T) {
+	seeds, err := SeedsFromOpenAPI(strings.NewReader(testSwagger2), "", '`')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seeds) != 2 {
+		t.Fatalf("Expected a seed per operation, got %d", len(seeds))
+	}
+
+	login := seeds[0].Request
+	body, _ := ioutil.ReadAll(login.Body)
+	if login.URL.String() != "http://example.com/api/login" || string(body) != "user=`admin`&remember=`true`" {
+		t.Fatalf("Expected a form body, got %s %s", login.URL, body)
+	}
+
+	users := seeds[1].Request
+	body, _ = ioutil.ReadAll(users.Body)
+	if string(body) != "{\"email\": \"`user@example.com`\"}" {
+		t.Fatalf("Expected a JSON body, got %s", body)
+	}
+
+	seeds, err = SeedsFromOpenAPI(strings.NewReader(testSwagger2), "https://staging.example.com/", '`')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if seeds[0].Request.URL.String() != "https://staging.example.com/login" {
+		t.Fatalf("Expected the base URL to override the document's, got %s", seeds[0].Request.URL)
+	}
+}
+
+func TestSeedsFromOpenAPIRejectsRemoteRefs(t *testing.T) {
+	document := strings.Replace(testOpenAPI3, "'#/components/schemas/User'", "'users.yaml#/User'", 1)
+	_, err := SeedsFromOpenAPI(strings.NewReader(document), "", '`')
+	if err == nil || !strings.Contains(err.Error(), "only local $refs are supported") {
+		t.Fatalf("Expected an error for a remote $ref, got %v", err)
+	}
+}
+
+func TestFuzzerFuzzesSeedTargets(t *testing.T) {
+	seeds, err := SeedsFromOpenAPI(strings.NewReader(testOpenAPI3), "", '`')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wordlist, err := os.Open("testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	config := &Config{
+		Wordlist:        &Wordlist{File: wordlist},
+		Seed:            seeds[0].Request,
+		Seeds:           seeds,
+		Client:          &Client{&http.Client{}},
+		TargetDelimiter: '`',
+		Logger:          testLogger(t),
+	}
+	fuzzer := &Fuzzer{config}
+	expectedCount, err := fuzzer.RequestCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	locations := map[string]int{}
+	for job := range requests {
+		locations[job.Location]++
+	}
+
+	// Both operations share the path parameter, GET adds a query parameter and a header and PUT adds 3 body properties, and each gets all 3 words.
+	expected := map[string]int{urlPathArgLocation: 6, urlParamLocation: 3, headerLocation: 3, bodyLocation: 9}
+	if expectedCount != 21 || !reflect.DeepEqual(locations, expected) {
+		t.Fatalf("Expected %v out of %d, got %v", expected, expectedCount, locations)
+	}
+}
+
+func TestSeedsFromOpenAPIFuzzesPathParametersSeparately(t *testing.T) {
+	document := `
+openapi: 3.0.1
+info:
+  title: Members
+  version: "1.0"
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /orgs/{orgId}/users/{userId}:
+    get:
+      parameters:
+        - name: orgId
+          in: path
+          schema:
+            type: integer
+        - name: userId
+          in: path
+          schema:
+            type: integer
+`
+	seeds, err := SeedsFromOpenAPI(strings.NewReader(document), "", '`')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed := seeds[0]
+	if seed.Request.URL.String() != "https://api.example.com/v1/orgs/1/users/2" {
+		t.Fatalf("Expected each path parameter to get its own value, got %s", seed.Request.URL)
+	}
+
+	wordlist, err := os.Open("testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	config := &Config{
+		Wordlist:        &Wordlist{File: wordlist},
+		Seed:            seed.Request,
+		Seeds:           seeds,
+		Client:          &Client{&http.Client{}},
+		TargetDelimiter: '`',
+		Logger:          testLogger(t),
+	}
+	fuzzer := &Fuzzer{config}
+
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	paths := []string{}
+	for job := range requests {
+		paths = append(paths, job.Request.URL.Path)
+	}
+
+	expected := []string{
+		"/v1/orgs/1/users/admin", "/v1/orgs/1/users/guest", "/v1/orgs/1/users/root",
+		"/v1/orgs/admin/users/2", "/v1/orgs/guest/users/2", "/v1/orgs/root/users/2",
+	}
+	sort.Strings(paths)
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Expected each path parameter to be fuzzed on its own, got %v", paths)
+	}
+}
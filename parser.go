@@ -4,35 +4,76 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net/http"
-	"os"
+	"strconv"
 )
 
-// RequestFromFile parses an HTTP request from a file.
-func RequestFromFile(filename string) (*Request, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
+// Seed is a request to fuzz, named after where it came from, like the method and URL of a HAR entry.
+// Seeds generated from an API description carry their own injection points, which are fuzzed along with the ones in the Config.
+type Seed struct {
+	Name           string
+	Request        *Request
+	TargetHeaders  []string
+	TargetParams   []string
+	TargetPathArgs []string
+}
+
+// withSeedTargets adds a seed's own injection points to the configured ones, skipping any that are already configured.
+func withSeedTargets(configured, seed []string) []string {
+	if len(seed) == 0 {
+		return configured
 	}
-	defer file.Close()
 
+	targets := append([]string{}, configured...)
+	seen := map[string]bool{}
+	for _, target := range configured {
+		seen[target] = true
+	}
+
+	for _, target := range seed {
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// uniquePathArg changes a generated path segment until no other segment in the URL has the same value.
+// Path arguments are found by value, so two parameters with the same example would otherwise be fuzzed as one.
+// Numbers are counted up so they stay numbers, and anything else gets a number appended.
+func uniquePathArg(segment string, taken map[string]bool) string {
+	unique := segment
+	number, err := strconv.Atoi(segment)
+	for i := 2; taken[unique]; i++ {
+		if err == nil {
+			number++
+			unique = strconv.Itoa(number)
+		} else {
+			unique = segment + strconv.Itoa(i)
+		}
+	}
+	taken[unique] = true
+	return unique
+}
+
+// RequestFromFile parses an HTTP request from a file.
+func RequestFromFile(filename string) (*Request, error) {
 	// Since we're letting the user specify injection points with a delimiter, the content length in the header will not match the body.
 	// Making them fix it by hand is awful so let's calculate it for them.
 	// This code only runs once at program startup: we're taking the performance hit now so the rest of the program can be efficient and usable.
-	diskRequestBytes, err := ioutil.ReadAll(file)
+	diskRequestBytes, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	// Move back to the head of the file so Go's parser can read it
-	_, err = file.Seek(0, io.SeekStart)
-	if err != nil {
-		return nil, err
-	}
+	return requestFromBytes(diskRequestBytes)
+}
 
-	req, err := http.ReadRequest(bufio.NewReader(file))
+// requestFromBytes parses a raw HTTP request, using everything after the headers as the body whatever the Content-Length says.
+func requestFromBytes(rawRequest []byte) (*Request, error) {
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(rawRequest)))
 	if err != nil {
 		return nil, err
 	}
@@ -45,20 +86,20 @@ func RequestFromFile(filename string) (*Request, error) {
 
 	// Replace the body in the seed request with the body on disk and adjust the content length.
 	// I know this is hacky, but there's tests and this is easier than reimplimenting http.ReadRequest.
-	bodyOffset := bytes.Index(diskRequestBytes, []byte("\n\n"))
-	if bodyOffset == -1 {
-		// Check for unix line endings too
-		bodyOffset = bytes.Index(diskRequestBytes, []byte("\r\n\r\n"))
-		if bodyOffset == -1 {
-			return nil, fmt.Errorf("invalid HTTP request provided")
-		} else {
-			bodyOffset += 4
-		}
-	} else {
-		bodyOffset += 2
+	// The headers end at whichever blank line comes first, so a body with the other line endings isn't split.
+	var bodyOffset int
+	lfOffset := bytes.Index(rawRequest, []byte("\n\n"))
+	crlfOffset := bytes.Index(rawRequest, []byte("\r\n\r\n"))
+	switch {
+	case crlfOffset != -1 && (lfOffset == -1 || crlfOffset < lfOffset):
+		bodyOffset = crlfOffset + 4
+	case lfOffset != -1:
+		bodyOffset = lfOffset + 2
+	default:
+		return nil, fmt.Errorf("invalid HTTP request provided")
 	}
 
-	diskBodyBytes := diskRequestBytes[bodyOffset:]
+	diskBodyBytes := rawRequest[bodyOffset:]
 	req.Body = ioutil.NopCloser(bytes.NewReader(diskBodyBytes))
 	req.ContentLength = int64(len(diskBodyBytes))
 
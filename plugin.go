@@ -14,9 +14,19 @@ type Listener interface {
 	Listen(results <-chan *Result)
 }
 
+// FailureListener is a Listener that also wants the results of requests that couldn't be sent, which have Error set and no Response.
+// Plain Listeners only receive results with a Response, so plugins written before failures were reported keep working.
+// ReceivesFailures is checked once, when the Listener is registered.
+type FailureListener interface {
+	Listener
+	ReceivesFailures() bool
+}
+
 type pluginInfo struct {
 	Input chan<- *Result
 	Listener
+	unfiltered bool
+	failures   bool
 }
 
 // InitializerFunc is a go function that should be exported by a function package.
@@ -25,13 +35,32 @@ type pluginInfo struct {
 type InitializerFunc func(*log.Logger) (Listener, error)
 
 // Result is the request, response and associated metadata to be processed by plugins.
+// Injections lists every payload in the request when the fuzzer placed payloads in several targets at once.
+// Attempts is the number of times the request was sent.
+// Started is when the last attempt was sent and TimeElapsed is how long it took.
+// If the request couldn't be sent after every attempt, Error says why and Response is nil, and the result is only sent to FailureListeners.
+// If it still got one of the retry status codes on its last attempt, Error is set and Response is the last response.
+// BodySize, Words and Lines measure the response body.
+// JobID is the position of the job in the order GenerateRequests created it.
+// If the fuzzer was calibrated, Baseline describes normal responses for the location and Deviation scores how far this response strays from them.
 type Result struct {
+	JobID       int
 	Request     *Request
 	Response    *Response
 	Payload     string
 	Location    string
 	FieldName   string
 	TimeElapsed time.Duration
+	Started     time.Time
+	Injections  []*Injection
+	Attempts    int
+	Error       error
+	BodySize    int64
+	Words       int
+	Lines       int
+	Baseline    *Baseline
+	Deviation   float64
+	findings    *Findings
 }
 
 // PluginBroker handles sending messages to plugins.
@@ -42,22 +71,39 @@ type PluginBroker struct {
 
 // SendResult sends a *Result to all loaded plugins for further processing.
 func (p *PluginBroker) SendResult(result *Result) error {
+	return p.sendResult(result, true)
+}
+
+// sendResult sends a *Result to the plugins that want it: every plugin if it passed the match and filter rules, otherwise only the unfiltered ones.
+// Results without a response only go to FailureListeners.
+func (p *PluginBroker) sendResult(result *Result, interesting bool) error {
 	for _, plugin := range p.plugins {
-		// Give each plugin its own request.
-		req, err := result.Request.CloneBody(context.Background())
-		if err != nil {
-			return err
+		if !interesting && !plugin.unfiltered {
+			continue
+		}
+
+		if result.Response == nil && !plugin.failures {
+			continue
 		}
 
-		resp, err := result.Response.CloneBody()
+		// Give each plugin its own result so they can read the bodies without getting in each other's way.
+		pluginResult := *result
+		req, err := result.Request.CloneBody(context.Background())
 		if err != nil {
 			return err
 		}
+		pluginResult.Request = req
+
+		// Failed requests don't have a response.
+		if result.Response != nil {
+			resp, err := result.Response.CloneBody()
+			if err != nil {
+				return err
+			}
+			pluginResult.Response = resp
+		}
 
-		result.Request = req
-		result.Response = resp
-
-		plugin.Input <- result
+		plugin.Input <- &pluginResult
 	}
 	return nil
 }
@@ -109,16 +155,37 @@ func LoadPlugins(logger *log.Logger, paths []string) (*PluginBroker, error) {
 			return nil, err
 		}
 
-		input := make(chan *Result)
-		httpfuzzPlugin := &pluginInfo{
-			Input:    input,
-			Listener: httpfuzzListener,
-		}
-
-		// Listen for results in a goroutine for each plugin
-		broker.add(httpfuzzPlugin)
-		broker.run(httpfuzzPlugin, input)
+		broker.Register(httpfuzzListener)
 	}
 
 	return broker, nil
 }
+
+// Register adds a Listener to the broker so it receives every result, the same way a plugin loaded from disk does.
+// It is used for httpfuzz's built-in outputs.
+func (p *PluginBroker) Register(listener Listener) {
+	p.register(listener, false)
+}
+
+// RegisterUnfiltered adds a Listener that receives every result, including the ones dropped by the match and filter rules or an anomaly threshold.
+// It is used for outputs that have to see every job, like the JUnit report.
+func (p *PluginBroker) RegisterUnfiltered(listener Listener) {
+	p.register(listener, true)
+}
+
+func (p *PluginBroker) register(listener Listener, unfiltered bool) {
+	input := make(chan *Result)
+	httpfuzzPlugin := &pluginInfo{
+		Input:      input,
+		Listener:   listener,
+		unfiltered: unfiltered,
+	}
+
+	if failureListener, ok := listener.(FailureListener); ok {
+		httpfuzzPlugin.failures = failureListener.ReceivesFailures()
+	}
+
+	// Listen for results in a goroutine for each plugin
+	p.add(httpfuzzPlugin)
+	p.run(httpfuzzPlugin, input)
+}
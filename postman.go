@@ -0,0 +1,610 @@
+package httpfuzz
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// postmanVariablePattern matches {{variable}} references in a Postman collection.
+var postmanVariablePattern = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// urlTargetMarker surrounds target variables in URLs while they're being resolved, since URLs can't contain it.
+const urlTargetMarker = "\x00"
+
+// PostmanCollection is a Postman Collection v2.1 file, as exported from Postman.
+type PostmanCollection struct {
+	Info     *PostmanInfo       `json:"info"`
+	Items    []*PostmanItem     `json:"item"`
+	Auth     *PostmanAuth       `json:"auth"`
+	Variable []*PostmanKeyValue `json:"variable"`
+}
+
+// PostmanInfo describes a collection.
+type PostmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// PostmanItem is either a request or a folder of items.
+type PostmanItem struct {
+	Name    string          `json:"name"`
+	Items   []*PostmanItem  `json:"item"`
+	Request json.RawMessage `json:"request"`
+	Auth    *PostmanAuth    `json:"auth"`
+}
+
+// PostmanRequest is a request in a collection.
+type PostmanRequest struct {
+	Method string             `json:"method"`
+	URL    json.RawMessage    `json:"url"`
+	Header []*PostmanKeyValue `json:"header"`
+	Body   *PostmanBody       `json:"body"`
+	Auth   *PostmanAuth       `json:"auth"`
+}
+
+// PostmanURL is a request URL broken into its parts.
+// Postman keeps the whole URL in Raw, but older exports may only have the parts.
+type PostmanURL struct {
+	Raw      string             `json:"raw"`
+	Protocol string             `json:"protocol"`
+	Host     json.RawMessage    `json:"host"`
+	Port     string             `json:"port"`
+	Path     json.RawMessage    `json:"path"`
+	Query    []*PostmanKeyValue `json:"query"`
+	Variable []*PostmanKeyValue `json:"variable"`
+}
+
+// PostmanBody is a request body in one of Postman's body modes.
+type PostmanBody struct {
+	Mode       string             `json:"mode"`
+	Raw        string             `json:"raw"`
+	URLEncoded []*PostmanKeyValue `json:"urlencoded"`
+	FormData   []*PostmanKeyValue `json:"formdata"`
+	GraphQL    *PostmanGraphQL    `json:"graphql"`
+	Options    struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+// PostmanGraphQL is a GraphQL request body.
+type PostmanGraphQL struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables"`
+}
+
+// PostmanAuth is the authentication a request, folder or collection uses.
+type PostmanAuth struct {
+	Type   string             `json:"type"`
+	Basic  []*PostmanKeyValue `json:"basic"`
+	Bearer []*PostmanKeyValue `json:"bearer"`
+	APIKey []*PostmanKeyValue `json:"apikey"`
+}
+
+// PostmanKeyValue is used throughout collections for headers, query params, form fields, variables and auth settings.
+type PostmanKeyValue struct {
+	Key      string          `json:"key"`
+	Value    json.RawMessage `json:"value"`
+	Type     string          `json:"type"`
+	Src      json.RawMessage `json:"src"`
+	Disabled bool            `json:"disabled"`
+	Enabled  *bool           `json:"enabled"`
+}
+
+// String returns the value, which Postman sometimes stores as a number or boolean.
+func (kv *PostmanKeyValue) String() string {
+	var value string
+	if json.Unmarshal(kv.Value, &value) == nil {
+		return value
+	}
+	return strings.TrimSpace(string(kv.Value))
+}
+
+// enabled is false for entries switched off in Postman, which are kept in the collection but not sent.
+func (kv *PostmanKeyValue) enabled() bool {
+	return !kv.Disabled && (kv.Enabled == nil || *kv.Enabled)
+}
+
+// PostmanEnvironment is a Postman environment file, as exported from Postman.
+type PostmanEnvironment struct {
+	Name   string             `json:"name"`
+	Values []*PostmanKeyValue `json:"values"`
+}
+
+// ReadPostmanEnvironment reads the variables from a Postman environment file.
+func ReadPostmanEnvironment(reader io.Reader) (map[string]string, error) {
+	environment := &PostmanEnvironment{}
+	err := json.NewDecoder(reader).Decode(environment)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := map[string]string{}
+	for _, value := range environment.Values {
+		if value.enabled() {
+			variables[value.Key] = value.String()
+		}
+	}
+	return variables, nil
+}
+
+// SeedsFromPostman generates a seed for every request in a Postman Collection v2.1, including the ones in nested folders.
+// {{variables}} are resolved from variables, like those from an environment, falling back to the collection's own.
+// Wherever one of the targetVariables is used, its value becomes an injection point: the header, query param or path segment it's in becomes one of the seed's targets, and it's surrounded with delimiter in bodies.
+// Path segments that would share a value with another segment are given their own, so each one is fuzzed separately.
+// Multipart form fields can't be targeted this way, so fuzz them with TargetMultipartFieldNames.
+func SeedsFromPostman(reader io.Reader, variables map[string]string, targetVariables []string, delimiter byte) ([]*Seed, error) {
+	collection := &PostmanCollection{}
+	err := json.NewDecoder(reader).Decode(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := &postmanResolver{variables: map[string]string{}, targets: map[string]bool{}, delimiter: delimiter}
+	for _, variable := range collection.Variable {
+		if variable.enabled() {
+			resolver.variables[variable.Key] = variable.String()
+		}
+	}
+
+	for name, value := range variables {
+		resolver.variables[name] = value
+	}
+
+	for _, name := range targetVariables {
+		resolver.targets[name] = true
+	}
+
+	seeds := []*Seed{}
+	err = resolver.collect(collection.Items, "", collection.Auth, &seeds)
+	return seeds, err
+}
+
+type postmanResolver struct {
+	variables map[string]string
+	targets   map[string]bool
+	delimiter byte
+}
+
+// collect walks a folder, adding a seed for every request in it and its subfolders.
+// Requests inherit the auth of the closest folder that sets it.
+func (r *postmanResolver) collect(items []*PostmanItem, folder string, auth *PostmanAuth, seeds *[]*Seed) error {
+	for _, item := range items {
+		name := item.Name
+		if folder != "" {
+			name = folder + " / " + item.Name
+		}
+
+		itemAuth := auth
+		if item.Auth != nil {
+			itemAuth = item.Auth
+		}
+
+		if len(item.Request) == 0 {
+			err := r.collect(item.Items, name, itemAuth, seeds)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		seed, err := r.seed(name, item.Request, itemAuth)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		*seeds = append(*seeds, seed)
+	}
+	return nil
+}
+
+func (r *postmanResolver) seed(name string, rawRequest json.RawMessage, auth *PostmanAuth) (*Seed, error) {
+	request := &PostmanRequest{}
+	// A request can be saved as just its URL.
+	var rawURL string
+	if json.Unmarshal(rawRequest, &rawURL) == nil {
+		request.URL, _ = json.Marshal(rawURL)
+	} else {
+		err := json.Unmarshal(rawRequest, request)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if request.Auth != nil {
+		auth = request.Auth
+	}
+
+	seed := &Seed{Name: name}
+	requestURL, err := r.url(request.URL, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	for _, header := range request.Header {
+		if !header.enabled() {
+			continue
+		}
+
+		key := r.resolve(header.Key, "")
+		headers.Add(key, r.resolve(header.String(), ""))
+		if r.usesTarget(header.String()) {
+			seed.TargetHeaders = append(seed.TargetHeaders, http.CanonicalHeaderKey(key))
+		}
+	}
+
+	body, contentType, err := r.body(request.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" && headers.Get("Content-Type") == "" {
+		headers.Set("Content-Type", contentType)
+	}
+
+	method := request.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var bodyReader io.Reader
+	if len(body) > 0 {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, requestURL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	// The fuzzer replaces the body, so don't let the client fall back to the generated one.
+	req.GetBody = nil
+	req.Header = headers
+	if host := headers.Get("Host"); host != "" {
+		req.Host = host
+		headers.Del("Host")
+	}
+
+	r.authenticate(req, auth, seed)
+	seed.Request = &Request{req}
+	return seed, nil
+}
+
+// url resolves a request's URL and registers the query params and path segments that use target variables.
+func (r *postmanResolver) url(rawURL json.RawMessage, seed *Seed) (string, error) {
+	postmanURL := &PostmanURL{}
+	if json.Unmarshal(rawURL, &postmanURL.Raw) != nil {
+		err := json.Unmarshal(rawURL, postmanURL)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	raw := postmanURL.Raw
+	if raw == "" {
+		raw = postmanURL.build()
+	}
+
+	// Fragments aren't sent.
+	raw = strings.SplitN(raw, "#", 2)[0]
+
+	base, query := raw, ""
+	if index := strings.Index(raw, "?"); index != -1 {
+		base, query = raw[:index], raw[index+1:]
+	}
+
+	// Target variables are marked so the path segments they end up in can be found once everything is resolved.
+	base = r.resolve(base, urlTargetMarker)
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+
+	// Path variables like :id are filled in from the URL's variables.
+	pathVariables := map[string]*PostmanKeyValue{}
+	for _, variable := range postmanURL.Variable {
+		pathVariables[variable.Key] = variable
+	}
+
+	schemeEnd := strings.Index(base, "://") + 3
+	if pathStart := strings.Index(base[schemeEnd:], "/"); pathStart != -1 {
+		pathStart += schemeEnd
+		segments := strings.Split(base[pathStart+1:], "/")
+		isTarget := make([]bool, len(segments))
+		takenSegments := map[string]bool{}
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				if variable, ok := pathVariables[segment[1:]]; ok {
+					segment = r.resolve(variable.String(), urlTargetMarker)
+				}
+			}
+
+			isTarget[i] = strings.Contains(segment, urlTargetMarker)
+			segments[i] = url.PathEscape(strings.Replace(segment, urlTargetMarker, "", -1))
+			if !isTarget[i] {
+				takenSegments[segments[i]] = true
+			}
+		}
+
+		// Path arguments are found by value, so every target segment needs a value no other segment has.
+		for i := range segments {
+			if isTarget[i] {
+				segments[i] = uniquePathArg(segments[i], takenSegments)
+				seed.TargetPathArgs = append(seed.TargetPathArgs, segments[i])
+			}
+		}
+		base = base[:pathStart+1] + strings.Join(segments, "/")
+	}
+	base = strings.Replace(base, urlTargetMarker, "", -1)
+
+	if query == "" {
+		return base, nil
+	}
+
+	params := []string{}
+	for _, param := range strings.Split(query, "&") {
+		parts := strings.SplitN(param, "=", 2)
+		key := r.resolve(parts[0], "")
+		if len(parts) == 1 {
+			params = append(params, url.QueryEscape(key))
+			continue
+		}
+
+		params = append(params, url.QueryEscape(key)+"="+url.QueryEscape(r.resolve(parts[1], "")))
+		if r.usesTarget(parts[1]) {
+			seed.TargetParams = append(seed.TargetParams, key)
+		}
+	}
+	return base + "?" + strings.Join(params, "&"), nil
+}
+
+// build puts a URL back together from its parts.
+func (u *PostmanURL) build() string {
+	raw := postmanStringOrList(u.Host, ".")
+	if u.Protocol != "" {
+		raw = u.Protocol + "://" + raw
+	}
+
+	if u.Port != "" {
+		raw += ":" + u.Port
+	}
+
+	if path := postmanStringOrList(u.Path, "/"); path != "" {
+		raw += "/" + strings.TrimPrefix(path, "/")
+	}
+
+	query := []string{}
+	for _, param := range u.Query {
+		if param.enabled() {
+			query = append(query, param.Key+"="+param.String())
+		}
+	}
+
+	if len(query) > 0 {
+		raw += "?" + strings.Join(query, "&")
+	}
+	return raw
+}
+
+// postmanStringOrList reads a URL part that Postman stores as either a string or a list of pieces.
+func postmanStringOrList(raw json.RawMessage, separator string) string {
+	var value string
+	if json.Unmarshal(raw, &value) == nil {
+		return value
+	}
+
+	var pieces []string
+	json.Unmarshal(raw, &pieces)
+	return strings.Join(pieces, separator)
+}
+
+// body builds a request body, surrounding target variables with the delimiter.
+// It returns the Content-Type for the body mode, which is only used if the request doesn't set one.
+func (r *postmanResolver) body(body *PostmanBody) ([]byte, string, error) {
+	if body == nil {
+		return nil, "", nil
+	}
+
+	switch body.Mode {
+	case "raw":
+		contentType := ""
+		switch body.Options.Raw.Language {
+		case "json":
+			contentType = "application/json"
+		case "xml":
+			contentType = "application/xml"
+		case "text":
+			contentType = "text/plain"
+		}
+		return []byte(r.resolve(body.Raw, string(r.delimiter))), contentType, nil
+	case "urlencoded":
+		form := []string{}
+		for _, field := range body.URLEncoded {
+			if !field.enabled() {
+				continue
+			}
+
+			value := url.QueryEscape(r.resolve(field.String(), ""))
+			if r.usesTarget(field.String()) {
+				value = string(r.delimiter) + value + string(r.delimiter)
+			}
+			form = append(form, url.QueryEscape(r.resolve(field.Key, ""))+"="+value)
+		}
+		return []byte(strings.Join(form, "&")), "application/x-www-form-urlencoded", nil
+	case "formdata":
+		return r.multipartBody(body.FormData)
+	case "graphql":
+		if body.GraphQL == nil {
+			return nil, "", nil
+		}
+
+		graphQL := map[string]interface{}{"query": r.resolve(body.GraphQL.Query, string(r.delimiter))}
+		if variables := r.resolve(body.GraphQL.Variables, string(r.delimiter)); strings.TrimSpace(variables) != "" {
+			graphQL["variables"] = json.RawMessage(variables)
+		}
+
+		encoded, err := json.Marshal(graphQL)
+		return encoded, "application/json", err
+	default:
+		return nil, "", nil
+	}
+}
+
+// multipartBody builds a multipart form.
+// File fields are sent empty, since the files they point at are on the machine the collection was saved on.
+func (r *postmanResolver) multipartBody(fields []*PostmanKeyValue) ([]byte, string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for _, field := range fields {
+		if !field.enabled() {
+			continue
+		}
+
+		header := textproto.MIMEHeader{}
+		disposition := fmt.Sprintf("form-data; name=%q", r.resolve(field.Key, ""))
+		value := r.resolve(field.String(), "")
+		if field.Type == "file" {
+			disposition += fmt.Sprintf("; filename=%q", filepath.Base(postmanStringOrList(field.Src, "")))
+			header.Set("Content-Type", "application/octet-stream")
+			value = ""
+		}
+		header.Set("Content-Disposition", disposition)
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+
+		_, err = io.WriteString(part, value)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+// authenticate adds basic, bearer and API key auth to a request.
+// Other auth types need Postman to sign the request, so they're skipped.
+func (r *postmanResolver) authenticate(req *http.Request, auth *PostmanAuth, seed *Seed) {
+	if auth == nil {
+		return
+	}
+
+	settings := func(values []*PostmanKeyValue) map[string]string {
+		resolved := map[string]string{}
+		for _, value := range values {
+			resolved[value.Key] = r.resolve(value.String(), "")
+			if r.usesTarget(value.String()) {
+				resolved["target:"+value.Key] = "true"
+			}
+		}
+		return resolved
+	}
+
+	switch auth.Type {
+	case "basic":
+		basic := settings(auth.Basic)
+		req.SetBasicAuth(basic["username"], basic["password"])
+	case "bearer":
+		bearer := settings(auth.Bearer)
+		req.Header.Set("Authorization", "Bearer "+bearer["token"])
+		if bearer["target:token"] != "" {
+			seed.TargetHeaders = append(seed.TargetHeaders, "Authorization")
+		}
+	case "apikey":
+		apiKey := settings(auth.APIKey)
+		if apiKey["in"] == "query" {
+			query := req.URL.Query()
+			query.Set(apiKey["key"], apiKey["value"])
+			req.URL.RawQuery = query.Encode()
+			if apiKey["target:value"] != "" {
+				seed.TargetParams = append(seed.TargetParams, apiKey["key"])
+			}
+			return
+		}
+
+		req.Header.Set(apiKey["key"], apiKey["value"])
+		if apiKey["target:value"] != "" {
+			seed.TargetHeaders = append(seed.TargetHeaders, http.CanonicalHeaderKey(apiKey["key"]))
+		}
+	}
+}
+
+// resolve replaces the {{variables}} in text with their values, surrounding target variables with marker.
+// Variables without a value are left as they are, like Postman does.
+func (r *postmanResolver) resolve(text, marker string) string {
+	// Variables can refer to other variables, so keep going until there's nothing left to replace.
+	for depth := 0; depth < 10 && postmanVariablePattern.MatchString(text); depth++ {
+		replaced := postmanVariablePattern.ReplaceAllStringFunc(text, func(reference string) string {
+			name := strings.TrimSpace(reference[2 : len(reference)-2])
+			value, ok := r.variables[name]
+			if !ok {
+				value, ok = postmanDynamicVariable(name)
+			}
+
+			if !ok {
+				return reference
+			}
+
+			if marker != "" && r.targets[name] {
+				return marker + r.resolve(value, "") + marker
+			}
+			return value
+		})
+
+		if replaced == text {
+			break
+		}
+		text = replaced
+	}
+	return text
+}
+
+// usesTarget returns true if text refers to one of the target variables.
+func (r *postmanResolver) usesTarget(text string) bool {
+	for _, match := range postmanVariablePattern.FindAllStringSubmatch(text, -1) {
+		if r.targets[strings.TrimSpace(match[1])] {
+			return true
+		}
+	}
+	return false
+}
+
+// postmanDynamicVariable generates values for the most common of Postman's built in variables, like {{$guid}}.
+func postmanDynamicVariable(name string) (string, bool) {
+	switch name {
+	case "$guid", "$randomUUID":
+		id := make([]byte, 16)
+		rand.Read(id)
+		id[6] = (id[6] & 0x0f) | 0x40
+		id[8] = (id[8] & 0x3f) | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]), true
+	case "$timestamp":
+		return strconv.FormatInt(time.Now().Unix(), 10), true
+	case "$isoTimestamp":
+		return time.Now().UTC().Format(time.RFC3339), true
+	case "$randomInt":
+		number := make([]byte, 2)
+		rand.Read(number)
+		return strconv.Itoa((int(number[0])<<8 | int(number[1])) % 1001), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,194 @@
+package httpfuzz
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPostmanCollection = `{
+  "info": {
+    "name": "Users",
+    "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+  },
+  "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]},
+  "variable": [
+    {"key": "baseUrl", "value": "https://example.com/api"},
+    {"key": "userId", "value": "1"},
+    {"key": "token", "value": "collection-token"}
+  ],
+  "item": [
+    {
+      "name": "Users",
+      "item": [
+        {
+          "name": "Admin",
+          "auth": {"type": "basic", "basic": [{"key": "username", "value": "admin"}, {"key": "password", "value": "hunter2"}]},
+          "item": [
+            {
+              "name": "Get user",
+              "request": {
+                "method": "GET",
+                "header": [
+                  {"key": "X-Tenant", "value": "{{tenant}}"},
+                  {"key": "X-Debug", "value": "1", "disabled": true}
+                ],
+                "url": {
+                  "raw": "{{baseUrl}}/users/{{userId}}/posts/:postId?fields={{fields}}&limit=10",
+                  "host": ["{{baseUrl}}"],
+                  "path": ["users", "{{userId}}", "posts", ":postId"],
+                  "variable": [{"key": "postId", "value": "{{postId}}"}]
+                }
+              }
+            }
+          ]
+        },
+        {
+          "name": "Create user",
+          "request": {
+            "method": "POST",
+            "header": [],
+            "body": {
+              "mode": "raw",
+              "raw": "{\"name\": \"{{name}}\", \"tenant\": \"{{tenant}}\"}",
+              "options": {"raw": {"language": "json"}}
+            },
+            "url": "{{baseUrl}}/users"
+          }
+        }
+      ]
+    },
+    {
+      "name": "Login",
+      "request": {
+        "method": "POST",
+        "auth": {"type": "noauth"},
+        "body": {
+          "mode": "urlencoded",
+          "urlencoded": [
+            {"key": "user", "value": "{{name}}"},
+            {"key": "password", "value": "secret"}
+          ]
+        },
+        "url": {"protocol": "http", "host": ["localhost"], "port": "8080", "path": ["login"]}
+      }
+    }
+  ]
+}`
+
+const testPostmanEnvironment = `{
+  "name": "Staging",
+  "values": [
+    {"key": "baseUrl", "value": "https://staging.example.com/api", "enabled": true},
+    {"key": "tenant", "value": "acme", "enabled": true},
+    {"key": "name", "value": "jon", "enabled": true},
+    {"key": "fields", "value": "email", "enabled": true},
+    {"key": "postId", "value": "7", "enabled": true},
+    {"key": "token", "value": "disabled-token", "enabled": false}
+  ]
+}`
+
+func testPostmanSeeds(t *testing.T) []*Seed {
+	variables, err := ReadPostmanEnvironment(strings.NewReader(testPostmanEnvironment))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seeds, err := SeedsFromPostman(strings.NewReader(testPostmanCollection), variables, []string{"userId", "fields", "tenant", "name", "postId"}, '`')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seeds) != 3 {
+		t.Fatalf("Expected a seed for every request in every folder, got %d", len(seeds))
+	}
+	return seeds
+}
+
+func TestSeedsFromPostmanResolvesVariables(t *testing.T) {
+	seeds := testPostmanSeeds(t)
+	get := seeds[0]
+	if get.Name != "Users / Admin / Get user" {
+		t.Fatalf("Expected the seed to be named after its folders, got %s", get.Name)
+	}
+
+	expectedURL := "https://staging.example.com/api/users/1/posts/7?fields=email&limit=10"
+	if get.Request.URL.String() != expectedURL {
+		t.Fatalf("Expected %s, got %s", expectedURL, get.Request.URL)
+	}
+
+	if get.Request.Header.Get("X-Tenant") != "acme" || get.Request.Header.Get("X-Debug") != "" {
+		t.Fatalf("Expected enabled headers only, got %v", get.Request.Header)
+	}
+
+	username, password, ok := get.Request.BasicAuth()
+	if !ok || username != "admin" || password != "hunter2" {
+		t.Fatalf("Expected basic auth from the closest folder, got %v", get.Request.Header)
+	}
+
+	create := seeds[1].Request
+	if create.Header.Get("Authorization") != "Bearer collection-token" {
+		t.Fatalf("Expected bearer auth from the collection, got %v", create.Header)
+	}
+
+	login := seeds[2].Request
+	if login.URL.String() != "http://localhost:8080/login" || login.Header.Get("Authorization") != "" {
+		t.Fatalf("Expected a URL built from its parts without auth, got %s %v", login.URL, login.Header)
+	}
+}
+
+func TestSeedsFromPostmanMarksTargetVariables(t *testing.T) {
+	seeds := testPostmanSeeds(t)
+	get := seeds[0]
+	if !reflect.DeepEqual(get.TargetPathArgs, []string{"1", "7"}) || !reflect.DeepEqual(get.TargetParams, []string{"fields"}) || !reflect.DeepEqual(get.TargetHeaders, []string{"X-Tenant"}) {
+		t.Fatalf("Expected target variables to become targets, got %+v", get)
+	}
+
+	body, err := ioutil.ReadAll(seeds[1].Request.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "{\"name\": \"`jon`\", \"tenant\": \"`acme`\"}" || seeds[1].Request.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected target variables to be delimited in the JSON body, got %s", body)
+	}
+
+	body, err = ioutil.ReadAll(seeds[2].Request.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "user=`jon`&password=secret" {
+		t.Fatalf("Expected target variables to be delimited in the form, got %s", body)
+	}
+}
+
+func TestSeedsFromPostmanGivesPathTargetsTheirOwnValues(t *testing.T) {
+	collection := `{
+  "info": {"name": "Members"},
+  "item": [
+    {
+      "name": "Get member",
+      "request": {
+        "method": "GET",
+        "url": "https://example.com/v1/orgs/{{orgId}}/users/{{userId}}/1"
+      }
+    }
+  ]
+}`
+	variables := map[string]string{"orgId": "1", "userId": "1"}
+	seeds, err := SeedsFromPostman(strings.NewReader(collection), variables, []string{"orgId", "userId"}, '`')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed := seeds[0]
+	if seed.Request.URL.String() != "https://example.com/v1/orgs/2/users/3/1" {
+		t.Fatalf("Expected each path target to get a value no other segment has, got %s", seed.Request.URL)
+	}
+
+	if !reflect.DeepEqual(seed.TargetPathArgs, []string{"2", "3"}) {
+		t.Fatalf("Expected 2 path targets, got %v", seed.TargetPathArgs)
+	}
+}
@@ -0,0 +1,144 @@
+package httpfuzz
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ProgressReporter periodically prints how far a run has got: requests completed out of the total, throughput, time remaining, errors and the status codes received.
+// On a terminal, set InPlace to redraw a single line instead of printing a new one every Interval.
+type ProgressReporter struct {
+	Writer   io.Writer
+	Total    int
+	Interval time.Duration
+	InPlace  bool
+	mux      sync.Mutex
+	line     string
+	started  time.Time
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewProgressReporter creates a ProgressReporter for a run of total requests.
+func NewProgressReporter(writer io.Writer, total int, interval time.Duration, inPlace bool) *ProgressReporter {
+	return &ProgressReporter{
+		Writer:   writer,
+		Total:    total,
+		Interval: interval,
+		InPlace:  inPlace,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start prints the progress returned by summary every Interval until Stop is called.
+func (p *ProgressReporter) Start(summary func() Summary) {
+	p.started = time.Now()
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.print(summary())
+			case <-p.stop:
+				// Finish with the final numbers so they stay on screen.
+				p.print(summary())
+				p.mux.Lock()
+				if p.InPlace {
+					io.WriteString(p.Writer, "\n")
+				}
+				p.line = ""
+				p.mux.Unlock()
+				return
+			}
+		}
+	}()
+}
+
+// Stop prints the progress one last time and stops reporting.
+func (p *ProgressReporter) Stop() {
+	if p == nil {
+		return
+	}
+
+	close(p.stop)
+	<-p.done
+}
+
+// Wrap returns a writer for output that shares the terminal with the progress line, like a log.Logger's.
+// When drawing in place, the progress line is cleared before the output is written and redrawn after it, so they don't get mixed up.
+func (p *ProgressReporter) Wrap(writer io.Writer) io.Writer {
+	return &progressWriter{reporter: p, writer: writer}
+}
+
+type progressWriter struct {
+	reporter *ProgressReporter
+	writer   io.Writer
+}
+
+func (w *progressWriter) Write(output []byte) (int, error) {
+	p := w.reporter
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if !p.InPlace || p.line == "" {
+		return w.writer.Write(output)
+	}
+
+	_, err := io.WriteString(p.Writer, "\r\x1b[K")
+	if err != nil {
+		return 0, err
+	}
+
+	written, err := w.writer.Write(output)
+	if err != nil {
+		return written, err
+	}
+
+	_, err = io.WriteString(p.Writer, p.line)
+	return written, err
+}
+
+func (p *ProgressReporter) print(summary Summary) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	line := formatProgress(summary, p.Total, time.Since(p.started))
+	if p.InPlace {
+		p.line = line
+		io.WriteString(p.Writer, "\r"+line+"\x1b[K")
+		return
+	}
+	io.WriteString(p.Writer, line+"\n")
+}
+
+// formatProgress describes a run's progress in a single line.
+// The ETA assumes the rest of the run goes as fast as it has so far.
+func formatProgress(summary Summary, total int, elapsed time.Duration) string {
+	completed := summary.Completed()
+	percent := 100.0
+	if total > 0 {
+		percent = float64(completed) / float64(total) * 100
+	}
+
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(completed) / elapsed.Seconds()
+	}
+
+	eta := "unknown"
+	switch {
+	case completed >= total:
+		eta = "0s"
+	case rate > 0:
+		eta = time.Duration(float64(total-completed) / rate * float64(time.Second)).Round(time.Second).String()
+	}
+
+	line := fmt.Sprintf("Progress: %d/%d (%.1f%%), %.1f req/s, ETA %s, %d errors", completed, total, percent, rate, eta, summary.Failed)
+	if len(summary.StatusCodes) > 0 {
+		line += ", statuses " + summary.Histogram()
+	}
+	return line
+}
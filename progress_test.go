@@ -0,0 +1,68 @@
+package httpfuzz
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatProgressEstimatesTimeRemaining(t *testing.T) {
+	summary := Summary{
+		Received:    90,
+		Failed:      10,
+		StatusCodes: map[int]int{404: 20, 200: 70},
+	}
+
+	line := formatProgress(summary, 400, 10*time.Second)
+	expected := "Progress: 100/400 (25.0%), 10.0 req/s, ETA 30s, 10 errors, statuses 200:70 404:20"
+	if line != expected {
+		t.Fatalf("Expected %s, got %s", expected, line)
+	}
+}
+
+func TestFormatProgressBeforeAnyRequests(t *testing.T) {
+	line := formatProgress(Summary{}, 400, 0)
+	expected := "Progress: 0/400 (0.0%), 0.0 req/s, ETA unknown, 0 errors"
+	if line != expected {
+		t.Fatalf("Expected %s, got %s", expected, line)
+	}
+}
+
+func TestProgressReporterPrintsFinalLineWhenStopped(t *testing.T) {
+	output := &bytes.Buffer{}
+	progress := NewProgressReporter(output, 2, time.Hour, false)
+	progress.Start(func() Summary {
+		return Summary{Received: 2, StatusCodes: map[int]int{200: 2}}
+	})
+	progress.Stop()
+
+	if !strings.HasPrefix(output.String(), "Progress: 2/2 (100.0%)") || !strings.HasSuffix(output.String(), "statuses 200:2\n") {
+		t.Fatalf("Expected a single final progress line, got %q", output.String())
+	}
+}
+
+func TestProgressReporterRedrawsAroundLogOutput(t *testing.T) {
+	output := &bytes.Buffer{}
+	progress := NewProgressReporter(output, 2, time.Hour, true)
+	progress.print(Summary{Received: 1})
+	if progress.line == "" {
+		t.Fatalf("Expected the progress line to be drawn")
+	}
+
+	logs := &bytes.Buffer{}
+	_, err := progress.Wrap(logs).Write([]byte("log line\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if logs.String() != "log line\n" {
+		t.Fatalf("Expected the log line to be written, got %q", logs.String())
+	}
+
+	// The progress line is cleared before the log line and drawn again after it.
+	expected := "\r" + progress.line + "\x1b[K" + "\r\x1b[K" + progress.line
+	if output.String() != expected {
+		t.Fatalf("Expected %q, got %q", expected, output.String())
+	}
+}
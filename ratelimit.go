@@ -0,0 +1,81 @@
+package httpfuzz
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket that caps how many requests are sent per second.
+// Up to Burst requests can be sent at once after a quiet period, then requests are spaced out evenly.
+// A nil *RateLimiter doesn't limit anything.
+type RateLimiter struct {
+	mux    sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a RateLimiter that allows requestsPerSecond requests every second with bursts of up to burst requests.
+// A rate of 0 or less disables the limit.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request can be sent without going over the rate limit, or returns the context's error if it's cancelled first.
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mux.Lock()
+	if r.rate <= 0 {
+		r.mux.Unlock()
+		return nil
+	}
+
+	r.refill()
+
+	// Take the token now even if it hasn't been earned yet.
+	// Callers that find the bucket empty queue up behind each other instead of racing for the next token.
+	r.tokens--
+	wait := time.Duration(-r.tokens / r.rate * float64(time.Second))
+	r.mux.Unlock()
+
+	return sleep(ctx, wait)
+}
+
+// sleep waits for duration unless the context is cancelled first.
+func sleep(ctx context.Context, duration time.Duration) error {
+	if duration <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// refill adds the tokens earned since the last refill.
+// The caller must hold the lock.
+func (r *RateLimiter) refill() {
+	now := time.Now()
+	r.tokens = math.Min(r.burst, r.tokens+now.Sub(r.last).Seconds()*r.rate)
+	r.last = now
+}
@@ -0,0 +1,61 @@
+package httpfuzz
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSpacesOutRequests(t *testing.T) {
+	limiter := NewRateLimiter(50, 1)
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		limiter.Wait(context.Background())
+	}
+	elapsed := time.Since(start)
+
+	// The first request goes out straight away and each of the other 5 waits 20ms.
+	const minimum = 90 * time.Millisecond
+	if elapsed < minimum {
+		t.Fatalf("Expected 6 requests to take at least %v, took %v", minimum, elapsed)
+	}
+}
+
+func TestRateLimiterAllowsBursts(t *testing.T) {
+	limiter := NewRateLimiter(1, 5)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		limiter.Wait(context.Background())
+	}
+	elapsed := time.Since(start)
+
+	const maximum = 100 * time.Millisecond
+	if elapsed > maximum {
+		t.Fatalf("Expected a burst of 5 requests to take less than %v, took %v", maximum, elapsed)
+	}
+}
+
+func TestNilRateLimiterDoesNotBlock(t *testing.T) {
+	var limiter *RateLimiter
+	limiter.Wait(context.Background())
+}
+
+func TestRateLimiterStopsWaitingWhenCancelled(t *testing.T) {
+	limiter := NewRateLimiter(0.001, 1)
+	limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := limiter.Wait(ctx)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected the context's error, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected Wait to return when cancelled, took %v", elapsed)
+	}
+}
@@ -0,0 +1,111 @@
+package httpfuzz
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// ResultRecord is a flat, serialisable copy of a Result used by httpfuzz's built-in outputs.
+// RawRequest and RawResponse hold the exchange exactly as it was sent and received, so it can be inspected or replayed later.
+// Row is only set on records loaded from a sqlitestore.Store, where it identifies the result across every run saved there.
+type ResultRecord struct {
+	Row           int          `json:"row,omitempty"`
+	ID            int          `json:"id"`
+	Location      string       `json:"location"`
+	FieldName     string       `json:"field_name"`
+	Payload       string       `json:"payload"`
+	Injections    []*Injection `json:"injections,omitempty"`
+	Method        string       `json:"method"`
+	URL           string       `json:"url"`
+	StatusCode    int          `json:"status_code,omitempty"`
+	Headers       http.Header  `json:"headers,omitempty"`
+	BodyLength    int64        `json:"body_length"`
+	Words         int          `json:"words"`
+	Lines         int          `json:"lines"`
+	BodySHA256    string       `json:"body_sha256,omitempty"`
+	TimeElapsedMS int64        `json:"time_elapsed_ms"`
+	Attempts      int          `json:"attempts"`
+	Deviation     float64      `json:"deviation,omitempty"`
+	Error         string       `json:"error,omitempty"`
+	RawRequest    []byte       `json:"raw_request"`
+	RawResponse   []byte       `json:"raw_response,omitempty"`
+}
+
+// NewResultRecord copies a Result into a ResultRecord.
+// It reads the request and response bodies and puts them back, so the Result can still be used afterwards.
+func NewResultRecord(result *Result) (*ResultRecord, error) {
+	rawRequest, err := httputil.DumpRequestOut(result.Request.Request, true)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &ResultRecord{
+		ID:            result.JobID,
+		Location:      result.Location,
+		FieldName:     result.FieldName,
+		Payload:       result.Payload,
+		Injections:    result.Injections,
+		Method:        result.Request.Method,
+		URL:           result.Request.URL.String(),
+		BodyLength:    result.BodySize,
+		Words:         result.Words,
+		Lines:         result.Lines,
+		TimeElapsedMS: result.TimeElapsed.Milliseconds(),
+		Attempts:      result.Attempts,
+		Deviation:     result.Deviation,
+		RawRequest:    rawRequest,
+	}
+
+	if result.Error != nil {
+		record.Error = result.Error.Error()
+	}
+
+	if result.Response == nil {
+		return record, nil
+	}
+
+	body, err := result.Response.BodyBytes()
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(body)
+
+	rawResponse, err := httputil.DumpResponse(result.Response.Response, true)
+	if err != nil {
+		return nil, err
+	}
+
+	record.StatusCode = result.Response.StatusCode
+	record.Headers = result.Response.Header
+	record.BodySHA256 = hex.EncodeToString(hash[:])
+	record.RawResponse = rawResponse
+	return record, nil
+}
+
+// Request rebuilds the request that was sent from RawRequest, so it can be sent again.
+func (r *ResultRecord) Request() (*Request, error) {
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(r.RawRequest)))
+	if err != nil {
+		return nil, err
+	}
+
+	// The raw request only has the path, so take the scheme and host from the URL it was sent to.
+	req.URL, err = url.Parse(r.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Go's transport adds this header itself and only decompresses the response when it did, so leave it to the transport.
+	if req.Header.Get("Accept-Encoding") == "gzip" {
+		req.Header.Del("Accept-Encoding")
+	}
+
+	// Prevent an error when sending the request
+	req.RequestURI = ""
+	return &Request{Request: req}, nil
+}
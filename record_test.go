@@ -0,0 +1,43 @@
+package httpfuzz
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestResultRecordRequestRebuildsSentRequest(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://localhost:8443/login?user=admin", strings.NewReader("password=hunter2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Payload", "' OR 1=1")
+	result := testResult(t, http.StatusOK, "Welcome admin")
+	result.Request = &Request{req}
+
+	record, err := NewResultRecord(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rebuilt, err := record.Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rebuilt.Method != "POST" || rebuilt.URL.String() != "https://localhost:8443/login?user=admin" || rebuilt.Host != "localhost:8443" {
+		t.Fatalf("Unexpected request %s %s with host %s", rebuilt.Method, rebuilt.URL, rebuilt.Host)
+	}
+
+	if rebuilt.Header.Get("X-Payload") != "' OR 1=1" || rebuilt.RequestURI != "" {
+		t.Fatalf("Unexpected headers %+v", rebuilt.Header)
+	}
+
+	body, err := ioutil.ReadAll(rebuilt.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "password=hunter2" {
+		t.Fatalf("Expected body password=hunter2, got %s", body)
+	}
+}
@@ -0,0 +1,372 @@
+package httpfuzz
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultMaxReportResults is how many results an HTMLReport lists when MaxResults isn't set.
+const DefaultMaxReportResults = 5000
+
+// maxReportExchangeBytes caps how much of each raw request and response is embedded in an HTML report, so a few large responses don't make the report too big to open.
+const maxReportExchangeBytes = 64 * 1024
+
+// HTMLReport is a Listener that collects every result and writes a single self-contained HTML file when the run finishes.
+// The report needs no network access to view, so it can be handed over as evidence after an engagement.
+// Only the first MaxResults results are listed with their exchanges, so big runs don't have to be held in memory; the rest are still counted in the summary.
+type HTMLReport struct {
+	Writer     io.Writer
+	Logger     *log.Logger
+	MaxResults int
+}
+
+// ReceivesFailures lists requests that couldn't be sent in the report as errors.
+func (h *HTMLReport) ReceivesFailures() bool {
+	return true
+}
+
+// Listen collects results until the channel is closed, then writes the report.
+// Results are summarised as they arrive, and exchanges are truncated, so big responses aren't held in memory for the whole run.
+func (h *HTMLReport) Listen(results <-chan *Result) {
+	maxResults := h.MaxResults
+	if maxResults < 1 {
+		maxResults = DefaultMaxReportResults
+	}
+
+	locations := newLocationSummariser()
+	records := []*ResultRecord{}
+	for result := range results {
+		record, err := NewResultRecord(result)
+		if err != nil {
+			h.Logger.Printf("Error recording result for job %d: %v", result.JobID, err)
+			continue
+		}
+
+		locations.add(record)
+		if len(records) == maxResults {
+			continue
+		}
+
+		record.RawRequest = truncateExchange(record.RawRequest)
+		record.RawResponse = truncateExchange(record.RawResponse)
+		records = append(records, record)
+	}
+
+	err := writeHTMLReport(h.Writer, locations.summaries(), records)
+	if err != nil {
+		h.Logger.Printf("Error writing HTML report: %v", err)
+	}
+}
+
+// LocationSummary aggregates the results for a single injection location in a report.
+type LocationSummary struct {
+	Location      string
+	FieldName     string
+	Requests      int
+	Errors        int
+	StatusCodes   []StatusCount
+	MinBodyLength int64
+	MaxBodyLength int64
+	AvgBodyLength int64
+	AvgTimeMS     int64
+	MaxTimeMS     int64
+	MaxDeviation  float64
+}
+
+// StatusCount is the number of responses with a status code.
+type StatusCount struct {
+	StatusCode int
+	Count      int
+}
+
+// SummariseLocations groups records by injection location, in the order each location first appears.
+func SummariseLocations(records []*ResultRecord) []*LocationSummary {
+	locations := newLocationSummariser()
+	for _, record := range records {
+		locations.add(record)
+	}
+	return locations.summaries()
+}
+
+// locationSummariser keeps running totals for each injection location, so records can be summarised one at a time as they arrive.
+type locationSummariser struct {
+	order           []*LocationSummary
+	byLocation      map[string]*LocationSummary
+	statusCodes     map[string]map[int]int
+	totalBodyLength map[string]int64
+	totalTime       map[string]int64
+}
+
+func newLocationSummariser() *locationSummariser {
+	return &locationSummariser{
+		byLocation:      map[string]*LocationSummary{},
+		statusCodes:     map[string]map[int]int{},
+		totalBodyLength: map[string]int64{},
+		totalTime:       map[string]int64{},
+	}
+}
+
+func (l *locationSummariser) add(record *ResultRecord) {
+	key := baselineKey(record.Location, record.FieldName)
+	summary, found := l.byLocation[key]
+	if !found {
+		summary = &LocationSummary{Location: record.Location, FieldName: record.FieldName, MinBodyLength: -1}
+		l.byLocation[key] = summary
+		l.statusCodes[key] = map[int]int{}
+		l.order = append(l.order, summary)
+	}
+
+	summary.Requests++
+	if record.Error != "" {
+		summary.Errors++
+		return
+	}
+
+	l.statusCodes[key][record.StatusCode]++
+	if summary.MinBodyLength == -1 || record.BodyLength < summary.MinBodyLength {
+		summary.MinBodyLength = record.BodyLength
+	}
+
+	if record.BodyLength > summary.MaxBodyLength {
+		summary.MaxBodyLength = record.BodyLength
+	}
+
+	if record.TimeElapsedMS > summary.MaxTimeMS {
+		summary.MaxTimeMS = record.TimeElapsedMS
+	}
+
+	if record.Deviation > summary.MaxDeviation {
+		summary.MaxDeviation = record.Deviation
+	}
+	l.totalBodyLength[key] += record.BodyLength
+	l.totalTime[key] += record.TimeElapsedMS
+}
+
+// summaries works out the averages and status code counts once every record has been added.
+func (l *locationSummariser) summaries() []*LocationSummary {
+	for key, summary := range l.byLocation {
+		responses := int64(summary.Requests - summary.Errors)
+		if responses == 0 {
+			summary.MinBodyLength = 0
+			continue
+		}
+		summary.AvgBodyLength = l.totalBodyLength[key] / responses
+		summary.AvgTimeMS = l.totalTime[key] / responses
+
+		for statusCode, count := range l.statusCodes[key] {
+			summary.StatusCodes = append(summary.StatusCodes, StatusCount{StatusCode: statusCode, Count: count})
+		}
+		sort.Slice(summary.StatusCodes, func(i, j int) bool {
+			return summary.StatusCodes[i].StatusCode < summary.StatusCodes[j].StatusCode
+		})
+	}
+	return l.order
+}
+
+type htmlReportData struct {
+	Generated   string
+	Requests    int
+	Errors      int
+	Hidden      int
+	StatusCodes []int
+	Locations   []*LocationSummary
+	Records     []*ResultRecord
+}
+
+// WriteHTMLReport writes a self-contained HTML report of a fuzzing run.
+// It has summary statistics per injection location, a sortable and filterable table of every result, and the raw request and response for each one.
+func WriteHTMLReport(writer io.Writer, records []*ResultRecord) error {
+	return writeHTMLReport(writer, SummariseLocations(records), records)
+}
+
+// writeHTMLReport writes a report listing records, which can be fewer than the results the location summaries count.
+func writeHTMLReport(writer io.Writer, locations []*LocationSummary, records []*ResultRecord) error {
+	// Results arrive in the order requests finish, so list them in the order they were generated.
+	records = append([]*ResultRecord{}, records...)
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+
+	data := &htmlReportData{
+		Generated: time.Now().Format(time.RFC1123),
+		Locations: locations,
+		Records:   records,
+	}
+
+	for _, location := range locations {
+		data.Requests += location.Requests
+		data.Errors += location.Errors
+	}
+	data.Hidden = data.Requests - len(records)
+
+	// Only the listed results can be filtered by status.
+	seen := map[int]bool{}
+	for _, record := range records {
+		if record.Error != "" {
+			continue
+		}
+
+		if !seen[record.StatusCode] {
+			seen[record.StatusCode] = true
+			data.StatusCodes = append(data.StatusCodes, record.StatusCode)
+		}
+	}
+	sort.Ints(data.StatusCodes)
+
+	return htmlReportTemplate.Execute(writer, data)
+}
+
+// truncateExchange cuts a raw request or response down to maxReportExchangeBytes, ending with a note of how much was cut.
+// The note fits inside the limit, so truncating an exchange again leaves it as it is.
+func truncateExchange(raw []byte) []byte {
+	if len(raw) <= maxReportExchangeBytes {
+		return raw
+	}
+
+	// The note can't be longer than one counting every byte.
+	kept := maxReportExchangeBytes - len(fmt.Sprintf("\n\n[truncated %d bytes]", len(raw)))
+	truncated := append([]byte{}, raw[:kept]...)
+	return append(truncated, fmt.Sprintf("\n\n[truncated %d bytes]", len(raw)-kept)...)
+}
+
+// exchangeText prepares a raw request or response for display, truncating anything over maxReportExchangeBytes.
+func exchangeText(raw []byte) string {
+	return string(truncateExchange(raw))
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"exchange":    exchangeText,
+	"lower":       strings.ToLower,
+	"statusClass": func(statusCode int) int { return statusCode / 100 },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>httpfuzz report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1, h2 { font-weight: 600; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; font-size: 0.9em; }
+th, td { border: 1px solid #ddd; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+th[data-sort] { cursor: pointer; }
+th[data-order="asc"]::after { content: " \25B2"; }
+th[data-order="desc"]::after { content: " \25BC"; }
+td.number { text-align: right; }
+tr.error td { background: #fdecea; }
+.status-2 { color: #1a7f37; }
+.status-3 { color: #0969da; }
+.status-4 { color: #9a6700; }
+.status-5 { color: #cf222e; }
+pre { white-space: pre-wrap; word-break: break-all; background: #f6f8fa; padding: 0.6em; max-height: 30em; overflow: auto; }
+.controls { margin-bottom: 1em; }
+.controls input { width: 25em; }
+</style>
+</head>
+<body>
+<h1>httpfuzz report</h1>
+<p>Generated {{.Generated}}. {{.Requests}} requests, {{.Errors}} errors.</p>
+
+<h2>Injection locations</h2>
+<table>
+<thead>
+<tr><th>Location</th><th>Field</th><th>Requests</th><th>Errors</th><th>Status codes</th><th>Body length (min / avg / max)</th><th>Time ms (avg / max)</th><th>Max deviation</th></tr>
+</thead>
+<tbody>
+{{range .Locations}}<tr>
+<td>{{.Location}}</td>
+<td>{{.FieldName}}</td>
+<td class="number">{{.Requests}}</td>
+<td class="number">{{.Errors}}</td>
+<td>{{range .StatusCodes}}<span class="status-{{statusClass .StatusCode}}">{{.StatusCode}}</span> &times; {{.Count}}<br>{{end}}</td>
+<td class="number">{{.MinBodyLength}} / {{.AvgBodyLength}} / {{.MaxBodyLength}}</td>
+<td class="number">{{.AvgTimeMS}} / {{.MaxTimeMS}}</td>
+<td class="number">{{printf "%.1f" .MaxDeviation}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+
+<h2>Results</h2>
+{{if .Hidden}}<p>Only the first {{len .Records}} results received are listed. The other {{.Hidden}} are counted in the injection locations above; write them with --output to see them all.</p>
+{{end}}<div class="controls">
+<input id="filter" type="search" placeholder="Filter by location, field, payload or URL">
+<select id="status">
+<option value="">All status codes</option>
+{{range .StatusCodes}}<option value="{{.}}">{{.}}</option>
+{{end}}<option value="error">Errors</option>
+</select>
+</div>
+<table id="results">
+<thead>
+<tr><th data-sort="number">Job</th><th data-sort="text">Location</th><th data-sort="text">Field</th><th data-sort="text">Payload</th><th data-sort="number">Status</th><th data-sort="number">Length</th><th data-sort="number">Words</th><th data-sort="number">Lines</th><th data-sort="number">Time ms</th><th data-sort="number">Deviation</th><th>Exchange</th></tr>
+</thead>
+<tbody>
+{{range .Records}}<tr data-status="{{if .Error}}error{{else}}{{.StatusCode}}{{end}}" data-search="{{lower .Location}} {{lower .FieldName}} {{lower .Payload}} {{lower .URL}}"{{if .Error}} class="error"{{end}}>
+<td class="number">{{.ID}}</td>
+<td>{{.Location}}</td>
+<td>{{.FieldName}}</td>
+<td><code>{{.Payload}}</code></td>
+<td class="number" data-value="{{.StatusCode}}">{{if .Error}}{{.Error}}{{else}}<span class="status-{{statusClass .StatusCode}}">{{.StatusCode}}</span>{{end}}</td>
+<td class="number">{{.BodyLength}}</td>
+<td class="number">{{.Words}}</td>
+<td class="number">{{.Lines}}</td>
+<td class="number">{{.TimeElapsedMS}}</td>
+<td class="number">{{printf "%.1f" .Deviation}}</td>
+<td><details><summary>{{.Method}} {{.URL}}</summary>
+<h4>Request</h4>
+<pre>{{exchange .RawRequest}}</pre>
+{{if .RawResponse}}<h4>Response</h4>
+<pre>{{exchange .RawResponse}}</pre>
+{{end}}</details></td>
+</tr>
+{{end}}</tbody>
+</table>
+
+<script>
+(function () {
+  var table = document.getElementById("results");
+  var body = table.tBodies[0];
+
+  table.querySelectorAll("th[data-sort]").forEach(function (header) {
+    header.addEventListener("click", function () {
+      var index = header.cellIndex;
+      var numeric = header.dataset.sort === "number";
+      var ascending = header.dataset.order !== "asc";
+      table.querySelectorAll("th[data-sort]").forEach(function (other) { delete other.dataset.order; });
+      header.dataset.order = ascending ? "asc" : "desc";
+
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[index].dataset.value || a.cells[index].textContent;
+        var y = b.cells[index].dataset.value || b.cells[index].textContent;
+        if (numeric) {
+          x = parseFloat(x);
+          y = parseFloat(y);
+        }
+        return (x < y ? -1 : x > y ? 1 : 0) * (ascending ? 1 : -1);
+      });
+      rows.forEach(function (row) { body.appendChild(row); });
+    });
+  });
+
+  var filter = document.getElementById("filter");
+  var status = document.getElementById("status");
+  function applyFilters() {
+    var query = filter.value.toLowerCase();
+    Array.prototype.forEach.call(body.rows, function (row) {
+      var visible = row.dataset.search.indexOf(query) !== -1 && (status.value === "" || row.dataset.status === status.value);
+      row.style.display = visible ? "" : "none";
+    });
+  }
+  filter.addEventListener("input", applyFilters);
+  status.addEventListener("change", applyFilters);
+})();
+</script>
+</body>
+</html>
+`))
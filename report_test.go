@@ -0,0 +1,141 @@
+package httpfuzz
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSummariseLocationsGroupsByLocation(t *testing.T) {
+	records := []*ResultRecord{
+		{ID: 1, Location: headerLocation, FieldName: "X-Test", StatusCode: 200, BodyLength: 10, TimeElapsedMS: 10},
+		{ID: 2, Location: urlParamLocation, FieldName: "q", StatusCode: 500, BodyLength: 100, TimeElapsedMS: 30},
+		{ID: 3, Location: headerLocation, FieldName: "X-Test", StatusCode: 404, BodyLength: 30, TimeElapsedMS: 50},
+		{ID: 4, Location: headerLocation, FieldName: "X-Test", Error: "connection refused"},
+	}
+
+	summaries := SummariseLocations(records)
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 locations, got %d", len(summaries))
+	}
+
+	header := summaries[0]
+	if header.Location != headerLocation || header.Requests != 3 || header.Errors != 1 {
+		t.Fatalf("Unexpected header summary %+v", header)
+	}
+
+	if header.MinBodyLength != 10 || header.AvgBodyLength != 20 || header.MaxBodyLength != 30 {
+		t.Fatalf("Expected body lengths 10/20/30, got %d/%d/%d", header.MinBodyLength, header.AvgBodyLength, header.MaxBodyLength)
+	}
+
+	if header.AvgTimeMS != 30 || header.MaxTimeMS != 50 {
+		t.Fatalf("Expected times 30/50, got %d/%d", header.AvgTimeMS, header.MaxTimeMS)
+	}
+
+	if len(header.StatusCodes) != 2 || header.StatusCodes[0].StatusCode != 200 || header.StatusCodes[1].StatusCode != 404 {
+		t.Fatalf("Unexpected status codes %+v", header.StatusCodes)
+	}
+}
+
+func TestWriteHTMLReportEscapesExchanges(t *testing.T) {
+	records := []*ResultRecord{
+		{
+			ID:          1,
+			Location:    headerLocation,
+			FieldName:   "X-Test",
+			Payload:     "<script>alert(1)</script>",
+			Method:      "GET",
+			URL:         "http://example.com/",
+			StatusCode:  200,
+			RawRequest:  []byte("GET / HTTP/1.1\r\nX-Test: <script>alert(1)</script>\r\n\r\n"),
+			RawResponse: []byte("HTTP/1.1 200 OK\r\n\r\n<h1>hello</h1>"),
+		},
+	}
+
+	var output bytes.Buffer
+	err := WriteHTMLReport(&output, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := output.String()
+	if strings.Contains(report, "<script>alert(1)</script>") || strings.Contains(report, "<h1>hello</h1>") {
+		t.Fatalf("Expected payloads and responses to be escaped")
+	}
+
+	if !strings.Contains(report, "&lt;h1&gt;hello&lt;/h1&gt;") {
+		t.Fatalf("Expected raw response in report")
+	}
+}
+
+func TestHTMLReportTruncatesExchangesAsTheyArrive(t *testing.T) {
+	body := strings.Repeat("A", 2*maxReportExchangeBytes)
+	req, _ := http.NewRequest("GET", "http://localhost:8000/", nil)
+	result := testResult(t, http.StatusOK, body)
+	result.Request = &Request{req}
+	result.Response.Body = ioutil.NopCloser(strings.NewReader(body))
+
+	results := make(chan *Result, 1)
+	results <- result
+	close(results)
+
+	var output bytes.Buffer
+	report := &HTMLReport{Writer: &output, Logger: testLogger(t)}
+	report.Listen(results)
+
+	if strings.Contains(output.String(), strings.Repeat("A", maxReportExchangeBytes)) {
+		t.Fatalf("Expected the response to be truncated")
+	}
+
+	if !strings.Contains(output.String(), "[truncated ") {
+		t.Fatalf("Expected a note that the response was truncated")
+	}
+}
+
+func TestTruncateExchangeStaysWithinLimit(t *testing.T) {
+	raw := bytes.Repeat([]byte("A"), maxReportExchangeBytes+100)
+	truncated := truncateExchange(raw)
+	if len(truncated) > maxReportExchangeBytes {
+		t.Fatalf("Expected at most %d bytes, got %d", maxReportExchangeBytes, len(truncated))
+	}
+
+	kept := bytes.IndexByte(truncated, '\n')
+	expectedNote := fmt.Sprintf("\n\n[truncated %d bytes]", len(raw)-kept)
+	if !bytes.HasSuffix(truncated, []byte(expectedNote)) {
+		t.Fatalf("Expected note %q, got %q", expectedNote, truncated[kept:])
+	}
+
+	if !bytes.Equal(truncateExchange(truncated), truncated) {
+		t.Fatalf("Expected truncating again to change nothing")
+	}
+}
+
+func TestHTMLReportCapsListedResults(t *testing.T) {
+	results := make(chan *Result, 5)
+	for i := 0; i < 5; i++ {
+		req, _ := http.NewRequest("GET", "http://localhost:8000/", nil)
+		result := testResult(t, http.StatusOK, "OK")
+		result.JobID = i
+		result.Request = &Request{req}
+		result.Location = headerLocation
+		result.FieldName = "X-User"
+		results <- result
+	}
+	close(results)
+
+	var output bytes.Buffer
+	report := &HTMLReport{Writer: &output, Logger: testLogger(t), MaxResults: 2}
+	report.Listen(results)
+
+	rows := strings.Count(output.String(), "<tr data-status=")
+	if rows != 2 {
+		t.Fatalf("Expected 2 listed results, got %d", rows)
+	}
+
+	if !strings.Contains(output.String(), "5 requests, 0 errors") || !strings.Contains(output.String(), "The other 3 are counted") {
+		t.Fatalf("Expected every result to be counted in the summary, got %s", output.String())
+	}
+}
@@ -0,0 +1,62 @@
+package httpfuzz
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy decides when a request is sent again.
+// Requests that fail with a transport error or come back with one of StatusCodes are sent up to MaxAttempts times in total.
+// The delay between attempts doubles from BaseDelay up to MaxDelay, with random jitter so workers don't retry in lockstep.
+// A nil *RetryPolicy sends every request once.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	StatusCodes []int
+}
+
+// maxAttempts returns how many times a request can be sent in total.
+func (r *RetryPolicy) maxAttempts() int {
+	if r == nil || r.MaxAttempts < 1 {
+		return 1
+	}
+	return r.MaxAttempts
+}
+
+// shouldRetry returns true if a request failed in a way that's worth retrying.
+// The response is nil if err is set.
+func (r *RetryPolicy) shouldRetry(response *Response, err error) bool {
+	if r == nil {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	for _, statusCode := range r.StatusCodes {
+		if response.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns how long to wait after a failed attempt, starting from attempt 1.
+// It uses full jitter: a random delay between 0 and the exponential backoff.
+func (r *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := r.BaseDelay
+	for i := 1; i < attempt && (r.MaxDelay <= 0 || delay < r.MaxDelay); i++ {
+		delay *= 2
+	}
+
+	if r.MaxDelay > 0 && delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
@@ -0,0 +1,251 @@
+package httpfuzz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoffStaysWithinBounds(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts: 10,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    50 * time.Millisecond,
+	}
+
+	for attempt := 1; attempt < 10; attempt++ {
+		delay := policy.backoff(attempt)
+		if delay < 0 || delay > policy.MaxDelay {
+			t.Fatalf("Backoff for attempt %d out of bounds: %v", attempt, delay)
+		}
+	}
+}
+
+type resultCollector struct {
+	mux     sync.Mutex
+	results []*Result
+}
+
+func (r *resultCollector) Listen(results <-chan *Result) {
+	for result := range results {
+		r.mux.Lock()
+		r.results = append(r.results, result)
+		r.mux.Unlock()
+	}
+}
+
+// ReceivesFailures lets tests check failed requests are reported.
+func (r *resultCollector) ReceivesFailures() bool {
+	return true
+}
+
+func testBroker(listener Listener) *PluginBroker {
+	broker := &PluginBroker{}
+	broker.Register(listener)
+	return broker
+}
+
+func TestFuzzerRetriesRetryableStatusCodes(t *testing.T) {
+	var mux sync.Mutex
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Lock()
+		received++
+		attempt := received
+		mux.Unlock()
+
+		if attempt < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	wordlist, err := os.Open("testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	collector := &resultCollector{}
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	config := &Config{
+		AttackMode: PitchforkAttack,
+		Targets: []*Target{
+			{Location: headerLocation, FieldName: "X-User", Wordlist: &Wordlist{File: wordlist}},
+		},
+		Seed:        &Request{request},
+		Client:      &Client{&http.Client{}},
+		Plugins:     testBroker(collector),
+		Concurrency: 1,
+		Retry: &RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			StatusCodes: []int{http.StatusServiceUnavailable},
+		},
+		Logger:    testLogger(t),
+		URLScheme: "http",
+	}
+	fuzzer := &Fuzzer{config}
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	fuzzer.ProcessRequests(context.Background(), requests)
+
+	if len(collector.results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(collector.results))
+	}
+
+	first := collector.results[0]
+	if first.Attempts != 3 || first.Response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected first job to succeed on attempt 3, got %d attempts and status %d", first.Attempts, first.Response.StatusCode)
+	}
+}
+
+func TestFuzzerReportsJobsThatExhaustRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	// Nothing is listening, so every request fails.
+	server.Close()
+
+	wordlist, err := os.Open("testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	collector := &resultCollector{}
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	config := &Config{
+		AttackMode: PitchforkAttack,
+		Targets: []*Target{
+			{Location: headerLocation, FieldName: "X-User", Wordlist: &Wordlist{File: wordlist}},
+		},
+		Seed:    &Request{request},
+		Client:  &Client{&http.Client{}},
+		Plugins: testBroker(collector),
+		Retry: &RetryPolicy{
+			MaxAttempts: 2,
+			BaseDelay:   time.Millisecond,
+		},
+		Logger:    testLogger(t),
+		URLScheme: "http",
+	}
+	fuzzer := &Fuzzer{config}
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	fuzzer.ProcessRequests(context.Background(), requests)
+
+	if len(collector.results) != 3 {
+		t.Fatalf("Expected 3 failed results, got %d", len(collector.results))
+	}
+
+	for _, result := range collector.results {
+		if result.Error == nil || result.Response != nil {
+			t.Fatalf("Expected a failed result without a response, got %+v", *result)
+		}
+
+		if result.Attempts != 2 {
+			t.Fatalf("Expected 2 attempts, got %d", result.Attempts)
+		}
+	}
+}
+
+// legacyListener only implements Listener, like plugins written before failures were reported.
+type legacyListener struct {
+	collector *resultCollector
+}
+
+func (l *legacyListener) Listen(results <-chan *Result) {
+	l.collector.Listen(results)
+}
+
+func TestFailuresOnlyReachFailureListeners(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	// Nothing is listening, so every request fails.
+	server.Close()
+
+	wordlist, err := os.Open("testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	failures := &resultCollector{}
+	legacy := &legacyListener{collector: &resultCollector{}}
+	broker := testBroker(failures)
+	broker.Register(legacy)
+
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	config := &Config{
+		TargetHeaders: []string{"X-User"},
+		Wordlist:      &Wordlist{File: wordlist},
+		Seed:          &Request{request},
+		Client:        &Client{&http.Client{}},
+		Plugins:       broker,
+		Logger:        testLogger(t),
+		URLScheme:     "http",
+	}
+	fuzzer := &Fuzzer{config}
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	fuzzer.ProcessRequests(context.Background(), requests)
+
+	if len(failures.results) != 3 {
+		t.Fatalf("Expected 3 failed results for the FailureListener, got %d", len(failures.results))
+	}
+
+	if len(legacy.collector.results) != 0 {
+		t.Fatalf("Expected plain Listeners to only get results with a response, got %d", len(legacy.collector.results))
+	}
+}
+
+func TestFuzzerFailsJobsThatStillGetRetryableStatusCodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	wordlist, err := os.Open("testdata/usernames.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wordlist.Close()
+
+	collector := &resultCollector{}
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	config := &Config{
+		TargetHeaders: []string{"X-User"},
+		Wordlist:      &Wordlist{File: wordlist},
+		Seed:          &Request{request},
+		Client:        &Client{&http.Client{}},
+		Plugins:       testBroker(collector),
+		Retry: &RetryPolicy{
+			MaxAttempts: 2,
+			BaseDelay:   time.Millisecond,
+			StatusCodes: []int{http.StatusServiceUnavailable},
+		},
+		Logger:    testLogger(t),
+		URLScheme: "http",
+	}
+	fuzzer := &Fuzzer{config}
+	requests, _ := fuzzer.GenerateRequests(context.Background())
+	fuzzer.ProcessRequests(context.Background(), requests)
+
+	if len(collector.results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(collector.results))
+	}
+
+	for _, result := range collector.results {
+		if result.Error == nil || result.Response == nil || result.Response.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("Expected a failed result with the last response, got %+v", *result)
+		}
+
+		if result.Attempts != 2 {
+			t.Fatalf("Expected 2 attempts, got %d", result.Attempts)
+		}
+	}
+
+	summary := fuzzer.Summary()
+	if summary.Failed != 3 || summary.Received != 0 {
+		t.Fatalf("Expected 3 failures and no responses counted, got %s", summary)
+	}
+}
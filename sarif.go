@@ -0,0 +1,182 @@
+package httpfuzz
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// findingRuleDescriptions describes the rules built into Matcher.
+// Rules reported by plugins are described by their ID.
+var findingRuleDescriptions = map[string]string{
+	StatusRule: "Response status code flagged as a finding",
+	SizeRule:   "Response body size flagged as a finding",
+	WordsRule:  "Response body word count flagged as a finding",
+	LinesRule:  "Response body line count flagged as a finding",
+	TimeRule:   "Response time flagged as a finding",
+	RegexRule:  "Response headers or body matched a finding regular expression",
+}
+
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool      *sarifTool       `json:"tool"`
+	Artifacts []*sarifArtifact `json:"artifacts"`
+	Results   []*sarifResult   `json:"results"`
+}
+
+type sarifTool struct {
+	Driver *sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	Version        string       `json:"version"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	ShortDescription *sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifArtifact struct {
+	Location *sarifArtifactLocation `json:"location"`
+	MimeType string                 `json:"mimeType"`
+	Contents *sarifArtifactContent  `json:"contents"`
+}
+
+type sarifArtifactLocation struct {
+	URI   string `json:"uri"`
+	Index *int   `json:"index,omitempty"`
+}
+
+type sarifArtifactContent struct {
+	Text   string `json:"text,omitempty"`
+	Binary string `json:"binary,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    *sarifMessage          `json:"message"`
+	Locations  []*sarifLocation       `json:"locations"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation"`
+}
+
+// WriteSARIF writes findings as a SARIF 2.1.0 log, so they can be shown alongside static analysis results in code scanning dashboards.
+// Each detector gets its own rule, and the raw request for each finding is attached as an artifact that its result points to.
+func WriteSARIF(writer io.Writer, findings []*Finding) error {
+	run := &sarifRun{
+		Tool: &sarifTool{Driver: &sarifDriver{
+			Name:           "httpfuzz",
+			Version:        httpfuzzVersion(),
+			InformationURI: "https://github.com/JonCooperWorks/httpfuzz",
+			Rules:          []*sarifRule{},
+		}},
+		Artifacts: []*sarifArtifact{},
+		Results:   []*sarifResult{},
+	}
+
+	ruleIndexes := map[string]int{}
+	artifactIndexes := map[int]int{}
+	for _, finding := range findings {
+		ruleIndex, found := ruleIndexes[finding.RuleID]
+		if !found {
+			description, found := findingRuleDescriptions[finding.RuleID]
+			if !found {
+				description = finding.RuleID
+			}
+
+			ruleIndex = len(run.Tool.Driver.Rules)
+			ruleIndexes[finding.RuleID] = ruleIndex
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, &sarifRule{
+				ID:               finding.RuleID,
+				ShortDescription: &sarifMessage{Text: description},
+			})
+		}
+
+		// Several detectors can flag the same request, so each request is only attached once.
+		record := finding.Record
+		uri := fmt.Sprintf("requests/job-%d.http", record.ID)
+		artifactIndex, found := artifactIndexes[record.ID]
+		if !found {
+			artifactIndex = len(run.Artifacts)
+			artifactIndexes[record.ID] = artifactIndex
+			run.Artifacts = append(run.Artifacts, &sarifArtifact{
+				Location: &sarifArtifactLocation{URI: uri},
+				MimeType: "message/http",
+				Contents: sarifContent(record.RawRequest),
+			})
+		}
+
+		properties := map[string]interface{}{
+			"jobId":     record.ID,
+			"method":    record.Method,
+			"url":       record.URL,
+			"payload":   record.Payload,
+			"location":  record.Location,
+			"fieldName": record.FieldName,
+		}
+
+		if record.StatusCode != 0 {
+			properties["statusCode"] = record.StatusCode
+		}
+
+		if len(record.Injections) > 0 {
+			properties["injections"] = record.Injections
+		}
+
+		index := artifactIndex
+		run.Results = append(run.Results, &sarifResult{
+			RuleID:    finding.RuleID,
+			RuleIndex: ruleIndex,
+			Level:     "warning",
+			Message: &sarifMessage{
+				Text: fmt.Sprintf("%s with payload \"%s\" in %s field \"%s\" of %s %s", finding.Message, record.Payload, record.Location, record.FieldName, record.Method, record.URL),
+			},
+			Locations: []*sarifLocation{
+				{PhysicalLocation: &sarifPhysicalLocation{ArtifactLocation: &sarifArtifactLocation{URI: uri, Index: &index}}},
+			},
+			Properties: properties,
+		})
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []*sarifRun{run},
+	})
+}
+
+// sarifContent embeds a raw request as text, or base64 encoded if it isn't valid UTF-8.
+func sarifContent(raw []byte) *sarifArtifactContent {
+	if utf8.Valid(raw) {
+		return &sarifArtifactContent{Text: string(raw)}
+	}
+	return &sarifArtifactContent{Binary: base64.StdEncoding.EncodeToString(raw)}
+}
@@ -0,0 +1,94 @@
+package httpfuzz
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteSARIFGroupsRulesAndArtifacts(t *testing.T) {
+	record := &ResultRecord{
+		ID:         3,
+		Location:   headerLocation,
+		FieldName:  "X-User",
+		Payload:    "root",
+		Method:     "GET",
+		URL:        "http://localhost/",
+		StatusCode: 500,
+		RawRequest: []byte("GET / HTTP/1.1\r\nX-User: root\r\n\r\n"),
+	}
+	findings := []*Finding{
+		{RuleID: RegexRule, Message: "Response matched a finding regular expression", Record: record},
+		{RuleID: StatusRule, Message: "Response had status code 500", Record: record},
+		{RuleID: "sqli", Message: "SQL error", Record: &ResultRecord{ID: 4, RawRequest: []byte{0xff}}},
+	}
+
+	output := &bytes.Buffer{}
+	err := WriteSARIF(output, findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Artifacts []struct {
+				Location struct {
+					URI string `json:"uri"`
+				} `json:"location"`
+				Contents struct {
+					Text   string `json:"text"`
+					Binary string `json:"binary"`
+				} `json:"contents"`
+			} `json:"artifacts"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Message   struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							Index int `json:"index"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	err = json.Unmarshal(output.Bytes(), &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Unexpected SARIF log %s", output.String())
+	}
+
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 3 || len(run.Artifacts) != 2 || len(run.Results) != 3 {
+		t.Fatalf("Expected 3 rules, 2 artifacts and 3 results, got %d, %d and %d", len(run.Tool.Driver.Rules), len(run.Artifacts), len(run.Results))
+	}
+
+	if run.Artifacts[0].Contents.Text != string(record.RawRequest) || run.Artifacts[1].Contents.Binary != "/w==" {
+		t.Fatalf("Unexpected artifacts %+v", run.Artifacts)
+	}
+
+	result := run.Results[1]
+	if result.RuleIndex != 1 || result.Locations[0].PhysicalLocation.ArtifactLocation.Index != 0 {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+
+	expectedMessage := "Response had status code 500 with payload \"root\" in header field \"X-User\" of GET http://localhost/"
+	if result.Message.Text != expectedMessage {
+		t.Fatalf("Expected message %s, got %s", expectedMessage, result.Message.Text)
+	}
+}
@@ -0,0 +1,295 @@
+// Package sqlitestore saves httpfuzz results to a SQLite database, so big runs can be sliced after the fact.
+// It's kept out of the httpfuzz package because the driver needs cgo, and plugins shouldn't have to link it.
+package sqlitestore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/joncooperworks/httpfuzz"
+
+	// Register the sqlite3 driver with database/sql.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS results (
+	id INTEGER PRIMARY KEY,
+	job_id INTEGER NOT NULL,
+	location TEXT NOT NULL,
+	field_name TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	injections TEXT,
+	method TEXT NOT NULL,
+	url TEXT NOT NULL,
+	status_code INTEGER NOT NULL,
+	headers TEXT,
+	body_length INTEGER NOT NULL,
+	words INTEGER NOT NULL,
+	lines INTEGER NOT NULL,
+	body_sha256 TEXT NOT NULL,
+	time_elapsed_ms INTEGER NOT NULL,
+	attempts INTEGER NOT NULL,
+	deviation REAL NOT NULL,
+	error TEXT NOT NULL,
+	raw_request BLOB,
+	raw_response BLOB
+);
+CREATE INDEX IF NOT EXISTS results_job_id ON results (job_id);
+CREATE INDEX IF NOT EXISTS results_status_code ON results (status_code);
+CREATE INDEX IF NOT EXISTS results_body_length ON results (body_length);
+CREATE INDEX IF NOT EXISTS results_time_elapsed_ms ON results (time_elapsed_ms);
+`
+
+const sqliteColumns = "job_id, location, field_name, payload, injections, method, url, status_code, headers, body_length, words, lines, body_sha256, time_elapsed_ms, attempts, deviation, error, raw_request, raw_response"
+
+// Store is a Listener that saves every result to a SQLite database as it arrives, so big runs can be sliced after the fact with Query.
+// Results are appended, so several runs can share a database.
+type Store struct {
+	db     *sql.DB
+	Logger *log.Logger
+}
+
+// Open opens or creates a SQLite results database.
+func Open(filename string, logger *log.Logger) (*Store, error) {
+	db, err := sql.Open("sqlite3", filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite only allows one writer at a time, so don't let database/sql open more connections than that.
+	db.SetMaxOpenConns(1)
+
+	// Write-ahead logging keeps inserting every result in its own transaction fast, without losing results if the run is killed.
+	_, err = db.Exec("PRAGMA journal_mode = WAL; PRAGMA synchronous = NORMAL;")
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	_, err = db.Exec(sqliteSchema)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db, Logger: logger}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// ReceivesFailures saves requests that couldn't be sent too, with their error.
+func (s *Store) ReceivesFailures() bool {
+	return true
+}
+
+// Listen saves results to the database until the channel is closed.
+func (s *Store) Listen(results <-chan *httpfuzz.Result) {
+	statement, err := s.db.Prepare(fmt.Sprintf("INSERT INTO results (%s) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", sqliteColumns))
+	if err != nil {
+		s.Logger.Printf("Error preparing results database: %v", err)
+		for range results {
+		}
+		return
+	}
+	defer statement.Close()
+
+	for result := range results {
+		record, err := httpfuzz.NewResultRecord(result)
+		if err != nil {
+			s.Logger.Printf("Error recording result for job %d: %v", result.JobID, err)
+			continue
+		}
+
+		err = insertResultRecord(statement, record)
+		if err != nil {
+			s.Logger.Printf("Error saving result for job %d: %v", result.JobID, err)
+		}
+	}
+}
+
+// insertResultRecord saves a record using the statement prepared by Listen.
+func insertResultRecord(statement *sql.Stmt, record *httpfuzz.ResultRecord) error {
+	injections, err := json.Marshal(record.Injections)
+	if err != nil {
+		return err
+	}
+
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		return err
+	}
+
+	_, err = statement.Exec(
+		record.ID,
+		record.Location,
+		record.FieldName,
+		record.Payload,
+		string(injections),
+		record.Method,
+		record.URL,
+		record.StatusCode,
+		string(headers),
+		record.BodyLength,
+		record.Words,
+		record.Lines,
+		record.BodySHA256,
+		record.TimeElapsedMS,
+		record.Attempts,
+		record.Deviation,
+		record.Error,
+		record.RawRequest,
+		record.RawResponse,
+	)
+	return err
+}
+
+// ResultQuery selects stored results.
+// A result must satisfy every field that's set, and a field with several ranges is satisfied if any of them contain the result's value.
+// Location matches any of the locations a payload was placed in, and Payload is a regular expression.
+type ResultQuery struct {
+	StatusCodes []httpfuzz.Range
+	Sizes       []httpfuzz.Range
+	Times       []httpfuzz.Range
+	Location    string
+	FieldName   string
+	Payload     *regexp.Regexp
+	Limit       int
+}
+
+// Query returns the stored results that match a query, ordered by when they were saved.
+func (s *Store) Query(query *ResultQuery) ([]*httpfuzz.ResultRecord, error) {
+	conditions := []string{}
+	arguments := []interface{}{}
+	addRanges := func(column string, ranges []httpfuzz.Range) {
+		if len(ranges) == 0 {
+			return
+		}
+
+		clauses := make([]string, len(ranges))
+		for i, r := range ranges {
+			clauses[i] = fmt.Sprintf("%s BETWEEN ? AND ?", column)
+			arguments = append(arguments, r.Min, r.Max)
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s)", strings.Join(clauses, " OR ")))
+	}
+	addRanges("status_code", query.StatusCodes)
+	addRanges("body_length", query.Sizes)
+	addRanges("time_elapsed_ms", query.Times)
+
+	statement := fmt.Sprintf("SELECT id, %s FROM results", sqliteColumns)
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += " ORDER BY id"
+
+	rows, err := s.db.Query(statement, arguments...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Locations, field names and payloads of multi-target jobs are joined into one column, and regular expressions can't run in SQLite, so they're matched here.
+	records := []*httpfuzz.ResultRecord{}
+	for rows.Next() {
+		if query.Limit > 0 && len(records) == query.Limit {
+			break
+		}
+
+		record, err := scanResultRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		if query.matches(record) {
+			records = append(records, record)
+		}
+	}
+	return records, rows.Err()
+}
+
+// Record returns the result saved in a row.
+// Job IDs start from 0 in every run, so results are looked up by the Row that Query returns instead.
+func (s *Store) Record(row int) (*httpfuzz.ResultRecord, error) {
+	rows, err := s.db.Query(fmt.Sprintf("SELECT id, %s FROM results WHERE id = ?", sqliteColumns), row)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no result in row %d", row)
+	}
+	return scanResultRecord(rows)
+}
+
+func (q *ResultQuery) matches(record *httpfuzz.ResultRecord) bool {
+	if q.Location != "" && !containsPart(record.Location, q.Location) {
+		return false
+	}
+
+	if q.FieldName != "" && !containsPart(record.FieldName, q.FieldName) {
+		return false
+	}
+	return q.Payload == nil || q.Payload.MatchString(record.Payload)
+}
+
+// containsPart checks if a value is one of the parts of a field that httpfuzz joined for a job with several targets.
+func containsPart(joined, value string) bool {
+	for _, part := range strings.Split(joined, ", ") {
+		if part == value {
+			return true
+		}
+	}
+	return false
+}
+
+func scanResultRecord(rows *sql.Rows) (*httpfuzz.ResultRecord, error) {
+	record := &httpfuzz.ResultRecord{}
+	var injections, headers string
+	err := rows.Scan(
+		&record.Row,
+		&record.ID,
+		&record.Location,
+		&record.FieldName,
+		&record.Payload,
+		&injections,
+		&record.Method,
+		&record.URL,
+		&record.StatusCode,
+		&headers,
+		&record.BodyLength,
+		&record.Words,
+		&record.Lines,
+		&record.BodySHA256,
+		&record.TimeElapsedMS,
+		&record.Attempts,
+		&record.Deviation,
+		&record.Error,
+		&record.RawRequest,
+		&record.RawResponse,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(injections), &record.Injections)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(headers), &record.Headers)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
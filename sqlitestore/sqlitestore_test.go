@@ -0,0 +1,209 @@
+package sqlitestore
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joncooperworks/httpfuzz"
+)
+
+const (
+	headerLocation   = "header"
+	urlParamLocation = "url param"
+)
+
+func testLogger(t *testing.T) *log.Logger {
+	return log.New(testWriter{t}, "test", log.LstdFlags)
+}
+
+type testWriter struct {
+	t *testing.T
+}
+
+func (tw testWriter) Write(p []byte) (n int, err error) {
+	tw.t.Log(string(p))
+	return len(p), nil
+}
+
+// testResult builds a result with a response, measured the way the fuzzer measures them.
+func testResult(t *testing.T, statusCode int, body string) *httpfuzz.Result {
+	response := &httpfuzz.Response{Response: &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{"Content-Type": []string{"text/html"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}}
+	return &httpfuzz.Result{
+		Response:    response,
+		TimeElapsed: 250 * time.Millisecond,
+		BodySize:    int64(len(body)),
+		Words:       len(bytes.Fields([]byte(body))),
+		Lines:       strings.Count(body, "\n") + 1,
+	}
+}
+
+func TestStoreSavesAndQueriesResults(t *testing.T) {
+	directory, err := ioutil.TempDir("", "httpfuzz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	store, err := Open(filepath.Join(directory, "results.db"), testLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	results := make(chan *httpfuzz.Result, 4)
+	for i, test := range []struct {
+		location   string
+		fieldName  string
+		payload    string
+		statusCode int
+		body       string
+	}{
+		{headerLocation, "X-User", "admin", http.StatusOK, "Welcome admin"},
+		{headerLocation, "X-User", "root", http.StatusForbidden, "Forbidden"},
+		{urlParamLocation, "id", "' OR 1=1", http.StatusInternalServerError, "SQL syntax error near line 1"},
+	} {
+		req, _ := http.NewRequest("GET", "http://localhost:8000/?id=1", nil)
+		result := testResult(t, test.statusCode, test.body)
+		result.JobID = i
+		result.Request = &httpfuzz.Request{Request: req}
+		result.Location = test.location
+		result.FieldName = test.fieldName
+		result.Payload = test.payload
+		results <- result
+	}
+
+	failedReq, _ := http.NewRequest("GET", "http://localhost:8000/", nil)
+	results <- &httpfuzz.Result{
+		JobID:       3,
+		Request:     &httpfuzz.Request{Request: failedReq},
+		Location:    headerLocation + ", " + urlParamLocation,
+		FieldName:   "X-User, id",
+		Payload:     "guest, 2",
+		Error:       errors.New("connection refused"),
+		TimeElapsed: time.Second,
+		Injections: []*httpfuzz.Injection{
+			{Location: headerLocation, FieldName: "X-User", Payload: "guest"},
+			{Location: urlParamLocation, FieldName: "id", Payload: "2"},
+		},
+	}
+	close(results)
+	store.Listen(results)
+
+	all, err := store.Query(&ResultQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(all) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(all))
+	}
+
+	stored := all[2]
+	if stored.Row != 3 || stored.ID != 2 || stored.StatusCode != 500 || stored.BodyLength != 28 || stored.Headers.Get("Content-Type") != "text/html" || !strings.Contains(string(stored.RawResponse), "SQL syntax") {
+		t.Fatalf("Unexpected stored result %+v", stored)
+	}
+
+	if all[3].Error != "connection refused" || len(all[3].Injections) != 2 || all[3].Injections[1].Payload != "2" {
+		t.Fatalf("Unexpected stored failure %+v", all[3])
+	}
+
+	tests := []struct {
+		query    *ResultQuery
+		expected []int
+	}{
+		{&ResultQuery{StatusCodes: []httpfuzz.Range{{Min: 400, Max: 599}}}, []int{1, 2}},
+		{&ResultQuery{Sizes: []httpfuzz.Range{{Min: 10, Max: 20}}}, []int{0}},
+		{&ResultQuery{Times: []httpfuzz.Range{{Min: 500, Max: 2000}}}, []int{3}},
+		{&ResultQuery{Location: urlParamLocation}, []int{2, 3}},
+		{&ResultQuery{Location: headerLocation, FieldName: "X-User", Payload: regexp.MustCompile("^r")}, []int{1}},
+		{&ResultQuery{Limit: 2}, []int{0, 1}},
+	}
+
+	for _, test := range tests {
+		records, err := store.Query(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids := []int{}
+		for _, record := range records {
+			ids = append(ids, record.ID)
+		}
+
+		if len(ids) != len(test.expected) {
+			t.Fatalf("Expected %v for %+v, got %v", test.expected, test.query, ids)
+		}
+
+		for i := range ids {
+			if ids[i] != test.expected[i] {
+				t.Fatalf("Expected %v for %+v, got %v", test.expected, test.query, ids)
+			}
+		}
+	}
+
+	record, err := store.Record(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record.Payload != "' OR 1=1" {
+		t.Fatalf("Expected job 2, got %+v", record)
+	}
+
+	_, err = store.Record(42)
+	if err == nil {
+		t.Fatalf("Expected error for missing row")
+	}
+}
+
+func TestStoreTellsRunsApart(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "results.db"), testLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// Every run numbers its jobs from 0, so both runs save a job 0.
+	for _, payload := range []string{"first run", "second run"} {
+		req, _ := http.NewRequest("GET", "http://localhost:8000/", nil)
+		result := testResult(t, http.StatusOK, "OK")
+		result.Request = &httpfuzz.Request{Request: req}
+		result.Payload = payload
+
+		results := make(chan *httpfuzz.Result, 1)
+		results <- result
+		close(results)
+		store.Listen(results)
+	}
+
+	all, err := store.Query(&ResultQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(all) != 2 || all[0].Row == all[1].Row {
+		t.Fatalf("Expected 2 results in different rows, got %+v", all)
+	}
+
+	record, err := store.Record(all[0].Row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record.ID != 0 || record.Payload != "first run" {
+		t.Fatalf("Expected job 0 from the first run, got %+v", record)
+	}
+}
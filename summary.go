@@ -0,0 +1,79 @@
+package httpfuzz
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Summary counts the requests a run has sent so far.
+type Summary struct {
+	// Received is the number of requests that got a response, whether or not it was reported.
+	Received int
+	// Failed is the number of requests that couldn't be sent, or still got a retry status code on their last attempt.
+	Failed int
+	// StatusCodes counts the responses received with each status code.
+	StatusCodes map[int]int
+}
+
+// Completed is the number of requests that have been sent, whether or not they got a response.
+func (s Summary) Completed() int {
+	return s.Received + s.Failed
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d responses received, %d requests failed", s.Received, s.Failed)
+}
+
+// Histogram lists how many responses had each status code, like "200:1100 404:131".
+func (s Summary) Histogram() string {
+	codes := make([]int, 0, len(s.StatusCodes))
+	for code := range s.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	counts := make([]string, len(codes))
+	for i, code := range codes {
+		counts[i] = fmt.Sprintf("%d:%d", code, s.StatusCodes[code])
+	}
+	return strings.Join(counts, " ")
+}
+
+// summary is a Summary that workers can update concurrently.
+type summary struct {
+	mux     sync.Mutex
+	counted Summary
+}
+
+func (s *summary) receive(statusCode int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.counted.StatusCodes == nil {
+		s.counted.StatusCodes = map[int]int{}
+	}
+	s.counted.Received++
+	s.counted.StatusCodes[statusCode]++
+}
+
+func (s *summary) fail() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.counted.Failed++
+}
+
+// Summary returns how many requests the fuzzer has sent so far.
+// It's safe to call while requests are being processed.
+func (f *Fuzzer) Summary() Summary {
+	f.summary.mux.Lock()
+	defer f.summary.mux.Unlock()
+
+	// Copy the status codes so workers can keep counting while the caller reads them.
+	counted := f.summary.counted
+	counted.StatusCodes = map[int]int{}
+	for code, count := range f.summary.counted.StatusCodes {
+		counted.StatusCodes[code] = count
+	}
+	return counted
+}
@@ -0,0 +1,207 @@
+package httpfuzz
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Throttle slows the fuzzer down when the target shows signs of back-pressure, and speeds it back up once the target recovers.
+// Back-pressure is a response with one of StatusCodes, a Retry-After header, or the share of failed and slow requests among the last Window requests reaching ErrorRateThreshold.
+// Every slowdown doubles the gap between requests, starting at MinDelay and capped at MaxDelay.
+// The target controls Retry-After, so pauses it asks for are capped at MaxPause.
+// After RecoverAfter healthy responses in a row, the gap is halved until requests flow freely again.
+// A nil *Throttle doesn't slow anything down.
+type Throttle struct {
+	StatusCodes        []int
+	ErrorRateThreshold float64
+	SlowResponse       time.Duration
+	Window             int
+	MinDelay           time.Duration
+	MaxDelay           time.Duration
+	Pause              time.Duration
+	MaxPause           time.Duration
+	RecoverAfter       int
+	Logger             *log.Logger
+
+	mux         sync.Mutex
+	delay       time.Duration
+	lastSend    time.Time
+	pausedUntil time.Time
+	outcomes    []bool
+	healthy     int
+}
+
+// NewThrottle returns a Throttle that backs off on 429 and 503 responses, Retry-After headers and half the requests in a window of 20 failing.
+func NewThrottle(logger *log.Logger) *Throttle {
+	return &Throttle{
+		StatusCodes:        []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+		ErrorRateThreshold: 0.5,
+		Window:             20,
+		MinDelay:           100 * time.Millisecond,
+		MaxDelay:           10 * time.Second,
+		Pause:              5 * time.Second,
+		MaxPause:           5 * time.Minute,
+		RecoverAfter:       10,
+		Logger:             logger,
+	}
+}
+
+// Wait blocks until the next request can be sent, honouring any pause and the current gap between requests.
+// It returns the context's error if the context is cancelled first.
+func (t *Throttle) Wait(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mux.Lock()
+	now := time.Now()
+	next := t.pausedUntil
+	if spaced := t.lastSend.Add(t.delay); spaced.After(next) {
+		next = spaced
+	}
+
+	if next.Before(now) {
+		next = now
+	}
+
+	// Reserve the slot so other workers line up behind this request.
+	t.lastSend = next
+	t.mux.Unlock()
+
+	return sleep(ctx, time.Until(next))
+}
+
+// Observe records the outcome of a request and adjusts the pace of the fuzzer.
+// The response is nil if err is set.
+func (t *Throttle) Observe(response *Response, err error, elapsed time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if err == nil && t.isBackPressure(response) {
+		pause := t.Pause
+		if retryAfter, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+			pause = retryAfter
+		}
+
+		if t.MaxPause > 0 && pause > t.MaxPause {
+			t.Logger.Printf("Target asked to wait %v, only pausing for %v", pause, t.MaxPause)
+			pause = t.MaxPause
+		}
+
+		t.pausedUntil = time.Now().Add(pause)
+		t.slowDown()
+		t.Logger.Printf("Target responded with %d, pausing for %v then sending a request every %v", response.StatusCode, pause, t.delay)
+		return
+	}
+
+	failed := err != nil || (t.SlowResponse > 0 && elapsed > t.SlowResponse)
+	if t.errorRateExceeded(failed) {
+		t.slowDown()
+		t.Logger.Printf("Too many failed or slow requests, sending a request every %v", t.delay)
+		return
+	}
+
+	if failed {
+		t.healthy = 0
+		return
+	}
+
+	t.healthy++
+	if t.delay > 0 && t.healthy >= t.RecoverAfter {
+		t.healthy = 0
+		t.delay /= 2
+		if t.delay < t.MinDelay {
+			t.delay = 0
+			t.Logger.Printf("Target has recovered, sending requests at full speed")
+			return
+		}
+		t.Logger.Printf("Target is recovering, sending a request every %v", t.delay)
+	}
+}
+
+func (t *Throttle) isBackPressure(response *Response) bool {
+	if response.Header.Get("Retry-After") != "" {
+		return true
+	}
+
+	for _, statusCode := range t.StatusCodes {
+		if response.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// errorRateExceeded records an outcome in the sliding window and reports if the window is full and the failure rate reached the threshold.
+// The window starts over after it trips so one bad patch doesn't keep slowing the fuzzer down.
+func (t *Throttle) errorRateExceeded(failed bool) bool {
+	if t.Window < 1 || t.ErrorRateThreshold <= 0 {
+		return false
+	}
+
+	t.outcomes = append(t.outcomes, failed)
+	if len(t.outcomes) > t.Window {
+		t.outcomes = t.outcomes[1:]
+	}
+
+	if len(t.outcomes) < t.Window {
+		return false
+	}
+
+	failures := 0
+	for _, outcome := range t.outcomes {
+		if outcome {
+			failures++
+		}
+	}
+
+	if float64(failures)/float64(len(t.outcomes)) < t.ErrorRateThreshold {
+		return false
+	}
+
+	t.outcomes = nil
+	return true
+}
+
+// slowDown doubles the gap between requests.
+// The caller must hold the lock.
+func (t *Throttle) slowDown() {
+	t.healthy = 0
+	if t.delay < t.MinDelay {
+		t.delay = t.MinDelay
+	} else {
+		t.delay *= 2
+	}
+
+	if t.MaxDelay > 0 && t.delay > t.MaxDelay {
+		t.delay = t.MaxDelay
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
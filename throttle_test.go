@@ -0,0 +1,100 @@
+package httpfuzz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testResponse(statusCode int, header http.Header) *Response {
+	return &Response{&http.Response{StatusCode: statusCode, Header: header}}
+}
+
+func TestThrottleBacksOffOnTooManyRequests(t *testing.T) {
+	throttle := NewThrottle(testLogger(t))
+	throttle.Observe(testResponse(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"2"}}), nil, time.Millisecond)
+
+	if throttle.delay != throttle.MinDelay {
+		t.Fatalf("Expected delay %v, got %v", throttle.MinDelay, throttle.delay)
+	}
+
+	pause := time.Until(throttle.pausedUntil)
+	if pause < time.Second || pause > 2*time.Second {
+		t.Fatalf("Expected to pause for about 2s, got %v", pause)
+	}
+
+	throttle.Observe(testResponse(http.StatusServiceUnavailable, http.Header{}), nil, time.Millisecond)
+	if throttle.delay != 2*throttle.MinDelay {
+		t.Fatalf("Expected delay to double to %v, got %v", 2*throttle.MinDelay, throttle.delay)
+	}
+}
+
+func TestThrottleRampsBackUpAfterRecovery(t *testing.T) {
+	throttle := NewThrottle(testLogger(t))
+	throttle.Observe(testResponse(http.StatusTooManyRequests, http.Header{}), nil, time.Millisecond)
+
+	for i := 0; i < throttle.RecoverAfter; i++ {
+		throttle.Observe(testResponse(http.StatusOK, http.Header{}), nil, time.Millisecond)
+	}
+
+	if throttle.delay != 0 {
+		t.Fatalf("Expected throttle to stop delaying requests, got %v", throttle.delay)
+	}
+}
+
+func TestThrottleBacksOffWhenErrorRateIsHigh(t *testing.T) {
+	throttle := NewThrottle(testLogger(t))
+	throttle.Window = 4
+
+	for i := 0; i < 2; i++ {
+		throttle.Observe(testResponse(http.StatusOK, http.Header{}), nil, time.Millisecond)
+		throttle.Observe(nil, errors.New("timeout"), time.Second)
+	}
+
+	if throttle.delay != throttle.MinDelay {
+		t.Fatalf("Expected delay %v, got %v", throttle.MinDelay, throttle.delay)
+	}
+}
+
+func TestParseRetryAfterAcceptsDates(t *testing.T) {
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	wait, ok := parseRetryAfter(date)
+	if !ok {
+		t.Fatalf("Expected %s to parse", date)
+	}
+
+	if wait <= 0 || wait > time.Minute {
+		t.Fatalf("Expected a wait of up to a minute, got %v", wait)
+	}
+}
+
+func TestThrottleStopsWaitingWhenCancelled(t *testing.T) {
+	throttle := NewThrottle(testLogger(t))
+	throttle.Observe(testResponse(http.StatusServiceUnavailable, http.Header{}), nil, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := throttle.Wait(ctx)
+	if err != context.Canceled {
+		t.Fatalf("Expected the context's error, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected Wait to return when cancelled, took %v", elapsed)
+	}
+}
+
+func TestThrottleCapsRetryAfter(t *testing.T) {
+	throttle := NewThrottle(testLogger(t))
+	throttle.MaxPause = time.Second
+	throttle.Observe(testResponse(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"86400"}}), nil, time.Millisecond)
+
+	pause := time.Until(throttle.pausedUntil)
+	if pause > time.Second {
+		t.Fatalf("Expected the pause to be capped at 1s, got %v", pause)
+	}
+}
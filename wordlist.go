@@ -38,6 +38,50 @@ func (w *Wordlist) Stream() <-chan string {
 	return payloads
 }
 
+// Word is a line from a wordlist and the byte offset in the file it starts at.
+type Word struct {
+	Text   string
+	Offset int64
+}
+
+// StreamFrom is like Stream, but starts at a byte offset and includes where each word starts.
+// Callers can record the offsets to pick up from the same word later.
+func (w *Wordlist) StreamFrom(offset int64) <-chan *Word {
+	words := make(chan *Word)
+
+	// Ensure only one stream can run at a time per wordlist.
+	w.mux.Lock()
+	go func(words chan<- *Word) {
+		defer w.mux.Unlock()
+		defer close(words)
+		// If there is no wordlist, just close the chan.
+		if w.File == nil {
+			return
+		}
+
+		_, err := w.File.Seek(offset, io.SeekStart)
+		if err != nil {
+			return
+		}
+
+		// Track how far into the file each line ends, since the scanner reads ahead of the words it returns.
+		next := offset
+		scanner := bufio.NewScanner(w.File)
+		scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			advance, token, err := bufio.ScanLines(data, atEOF)
+			next += int64(advance)
+			return advance, token, err
+		})
+
+		start := offset
+		for scanner.Scan() {
+			words <- &Word{Text: scanner.Text(), Offset: start}
+			start = next
+		}
+	}(words)
+	return words
+}
+
 // Count returns the number of words in a wordlist.
 func (w *Wordlist) Count() (int, error) {
 	// If there's no wordlist, there are no files in it.
@@ -48,6 +92,13 @@ func (w *Wordlist) Count() (int, error) {
 	// We don't want to start a count in the middle of a stream.
 	w.mux.Lock()
 	defer w.mux.Unlock()
+
+	// Count from the top, since a finished stream leaves the file at the end.
+	_, err := w.File.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
 	count := 1
 	const lineBreak = '\n'
 
@@ -74,10 +125,23 @@ func (w *Wordlist) Count() (int, error) {
 	}
 
 	// Move back to the head of the file
-	_, err := w.File.Seek(0, io.SeekStart)
+	_, err = w.File.Seek(0, io.SeekStart)
 	if err != nil {
 		return count, err
 	}
 
 	return count, nil
 }
+
+// Rewind moves back to the start of the wordlist so it can be streamed again.
+func (w *Wordlist) Rewind() error {
+	if w.File == nil {
+		return nil
+	}
+
+	// Don't move the file out from under a running stream.
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	_, err := w.File.Seek(0, io.SeekStart)
+	return err
+}
@@ -59,3 +59,30 @@ func TestWordlistCountIsAccurateEmptyWordlist(t *testing.T) {
 		t.Fatalf("Expected %d words, got %d", count, wordsReceived)
 	}
 }
+
+func TestWordlistStreamFromResumesAtOffset(t *testing.T) {
+	wlFile, err := os.Open("./testdata/passwords.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wlFile.Close()
+
+	wordlist := &Wordlist{File: wlFile}
+	words := []*Word{}
+	for word := range wordlist.StreamFrom(0) {
+		words = append(words, word)
+	}
+
+	if len(words) != 4 || words[0].Offset != 0 {
+		t.Fatalf("Expected 4 words starting at 0, got %d", len(words))
+	}
+
+	resumed := []*Word{}
+	for word := range wordlist.StreamFrom(words[2].Offset) {
+		resumed = append(resumed, word)
+	}
+
+	if len(resumed) != 2 || resumed[0].Text != words[2].Text || resumed[1].Text != words[3].Text || resumed[1].Offset != words[3].Offset {
+		t.Fatalf("Expected to resume at %s, got %+v", words[2].Text, resumed)
+	}
+}